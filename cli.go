@@ -1,35 +1,57 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"regexp"
 	"strings"
 
-	"github.com/parnurzeal/gorequest"
+	"github.com/Dexecure/dexecure-cli/client"
 	"github.com/tucnak/store"
 	"github.com/urfave/cli/v2"
 )
 
-var apiEndPoint = "https://dao-api.dexecure.com/api/v1/"
-var errorResponse ErrorResponse
+// saveToken saves token, and the endpoint given with --endpoint if any, in
+// the selected profile.
+func saveToken(c *cli.Context, token string) error {
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
 
-func saveToken(token string) {
-	var tokenSettings TokenSettings
-	tokenSettings.Token = token
-	if err := store.Save("token.json", &tokenSettings); err != nil {
-		fmt.Println("failed to save the token:", err)
-		return
+	name := profileName(c, config)
+	profile, ok := config.Profiles[name]
+	if !ok {
+		profile = &Profile{}
+		config.Profiles[name] = profile
+	}
+	profile.Token = token
+	if endpoint := c.String("endpoint"); endpoint != "" {
+		profile.Endpoint = endpoint
 	}
+	if config.CurrentProfile == "" {
+		config.CurrentProfile = name
+	}
+	return saveConfig(config)
 }
 
-func getToken() string {
-	var tokenSettings TokenSettings
-	store.Load("token.json", &tokenSettings)
-	return tokenSettings.Token
+func getToken(c *cli.Context) string {
+	s, err := resolveSettings(c)
+	if err != nil {
+		return ""
+	}
+	return s.Token.Value
+}
+
+func newClient(c *cli.Context) *client.Client {
+	cl := client.New(getToken(c))
+	if s, err := resolveSettings(c); err == nil {
+		cl.Endpoint = s.Endpoint.Value
+	}
+	cl.MaxRetries = c.Int("max-retries")
+	cl.Timeout = c.Duration("timeout")
+	return cl
 }
 
 func isValidUUID(uuid string) bool {
@@ -37,55 +59,37 @@ func isValidUUID(uuid string) bool {
 	return r.MatchString(uuid)
 }
 
-func parseResponse(body string, res gorequest.Response) Response {
-
-	var response Response
-
-	if res.StatusCode == 200 {
-		var responseJSON map[string]interface{}
-		json.Unmarshal([]byte(body), &responseJSON)
-
-		// hack 1 for actionhero validation errors
-		_, ok := responseJSON["error"].(string)
-		if ok {
-			response.Error = make(map[string]interface{})
-			response.Error["description"] = responseJSON["error"].(string)
-			return response
-		}
-
-		// hack 2 for actionhero validation errors
-		if responseJSON["error"] != nil {
-			response.Error = responseJSON["error"].(map[string]interface{})
-			return response
-		}
+var websiteTypes = []string{"magento", "wordpress", "shopify", "none"}
 
-		responseStatus := responseJSON["status"].(float64)
-
-		if responseStatus == 200 {
-			_, ok := responseJSON["data"].(string)
-			if ok {
-				response.Data = make(map[string]interface{})
-				response.Data["message"] = responseJSON["data"].(string)
-			} else {
-				response.Data = responseJSON["data"].(map[string]interface{})
-			}
-		} else {
-			response.Error = responseJSON["error"].(map[string]interface{})
+func isValidWebsiteType(websiteType string) bool {
+	for _, t := range websiteTypes {
+		if websiteType == t {
+			return true
 		}
-	} else {
-		fmt.Println("Request to the API failed")
 	}
-
-	return response
+	return false
 }
 
-func credentials() string {
-	reader := bufio.NewReader(os.Stdin)
-
-	fmt.Print("Enter your api token (Visit https://app.dexecure.com/api-tokens to generate a token if you don't have one already). Entering an empty token will clear any stored API token in this session: ")
-	apiTokens, _ := reader.ReadString('\n')
+// splitURLs splits a comma separated list of relative urls.
+func splitURLs(urls string) []string {
+	urlSlice := strings.Split(urls, ",")
+	for i := range urlSlice {
+		urlSlice[i] = strings.TrimSpace(urlSlice[i])
+	}
+	return urlSlice
+}
 
-	return strings.TrimSpace(apiTokens)
+// credentials reads the API token from stdin, without echoing it when stdin
+// is a terminal.
+func credentials(c *cli.Context) (string, error) {
+	if c.Bool("token-stdin") {
+		token, err := ioutil.ReadAll(os.Stdin)
+		return strings.TrimSpace(string(token)), err
+	}
+	if !isTerminal() {
+		return "", validationErr("Missing API token. Use --token-stdin to read it from stdin when stdin is not a terminal")
+	}
+	return promptSecret("Enter your api token (Visit https://app.dexecure.com/api-tokens to generate a token if you don't have one already). Entering an empty token will clear any stored API token in this session: ")
 }
 
 func main() {
@@ -97,6 +101,8 @@ func main() {
 	app.Version = "0.0.5"
 	app.Copyright = "Dexecure PTE LTD."
 	app.EnableBashCompletion = true
+	app.Flags = append(append(outputFlags, yesFlag), settingFlags...)
+	app.Before = checkOutputFormat
 
 	// config management
 	store.Init("dexecure")
@@ -106,10 +112,38 @@ func main() {
 			Name:    "configure",
 			Aliases: []string{"c"},
 			Usage:   "Add your Dexecure API token",
+			Flags: []cli.Flag{
+				profileFlag,
+				endpointFlag,
+				&cli.BoolFlag{Name: "token-stdin", Usage: "read the API token from stdin"},
+			},
 			Action: func(c *cli.Context) error {
-				apiTokens := credentials()
-				saveToken(apiTokens)
-				fmt.Println("API token saved successfully.")
+				apiTokens, err := credentials(c)
+				if err != nil {
+					return err
+				}
+
+				var user *client.User
+				if apiTokens != "" {
+					cl := newClient(c)
+					cl.Token = apiTokens
+					user, err = cl.GetUser()
+					if err != nil {
+						if exitCode(err) == exitAuth {
+							return cli.Exit("The API token was rejected by Dexecure. Nothing was saved.", exitAuth)
+						}
+						return exitErr(err)
+					}
+				}
+
+				if err := saveToken(c, apiTokens); err != nil {
+					return cli.Exit("failed to save the token: "+err.Error(), exitError)
+				}
+				if user == nil {
+					fmt.Println("API token cleared.")
+					return nil
+				}
+				fmt.Printf("API token saved successfully. Logged in as %s %s <%s>.\n", user.FirstName, user.LastName, user.Email)
 				return nil
 			},
 		},
@@ -118,57 +152,34 @@ func main() {
 			Aliases: []string{"l"},
 			Usage:   "Your Dexecure usage for this month",
 			Action: func(c *cli.Context) error {
-				if getToken() == "" {
-					fmt.Println("API token not found. Please run \"dexecure-cli configure\"")
-					return nil
+				if getToken(c) == "" {
+					return errNoToken
 				}
-				res, _, err := gorequest.
-					New().
-					Get(apiEndPoint+"user").
-					Set("Authorization", getToken()).
-					End()
 
+				user, err := newClient(c).GetUser()
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return exitErr(err)
 				}
 
-				// Read response body
-				bdy, er := ioutil.ReadAll(res.Body)
-				if er != nil {
-					return er
-				}
-				var user UserResponse
-				er = json.Unmarshal(bdy, &user)
-
-				res, _, err = gorequest.
-					New().
-					Get(apiEndPoint+"team/usage").
-					Set("Authorization", getToken()).
-					End()
-
+				usage, err := newClient(c).GetUsage()
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return exitErr(err)
 				}
 
-				// Read response body
-				bdy, er = ioutil.ReadAll(res.Body)
-				if er != nil {
-					return er
+				summary := usageSummary{
+					Usage:            *usage,
+					MaxBandwidth:     user.Plan.MaxBandwidth,
+					MaxRequests:      user.Plan.MaxRequests,
+					MaxDistributions: user.Plan.MaxDistributions,
 				}
-
-				var usage UsageResponse
-				er = json.Unmarshal(bdy, &usage)
-
-				fmt.Println("Bandwidth Used:")
-				fmt.Println(usage.Data.Bandwidth/(1024*1024), "MB of", user.Data.Plan.MaxBandwidth, "GB")
-				fmt.Println("Number of Requests:")
-				fmt.Println(usage.Data.Requests, "of", user.Data.Plan.MaxRequests)
-				fmt.Println("Number of Distributions Used:")
-				fmt.Println(usage.Data.Distributions, "of", user.Data.Plan.MaxDistributions)
-
-				return nil
+				return render(c, summary, func() {
+					fmt.Println("Bandwidth Used:")
+					fmt.Println(usage.Bandwidth/(1024*1024), "MB of", user.Plan.MaxBandwidth, "GB")
+					fmt.Println("Number of Requests:")
+					fmt.Println(usage.Requests, "of", user.Plan.MaxRequests)
+					fmt.Println("Number of Distributions Used:")
+					fmt.Println(usage.Distributions, "of", user.Plan.MaxDistributions)
+				})
 			},
 		},
 		{
@@ -184,9 +195,8 @@ func main() {
 							Name:  "id",
 							Usage: "information about your website",
 							Action: func(c *cli.Context) error {
-								if getToken() == "" {
-									fmt.Println("API token not found. Please run \"dexecure-cli configure\"")
-									return nil
+								if getToken(c) == "" {
+									return errNoToken
 								}
 
 								id := ""
@@ -194,107 +204,43 @@ func main() {
 									id = c.Args().First()
 									id = strings.TrimSpace(id)
 									if isValidUUID(id) == false {
-										fmt.Println("Please enter a valid website ID. It must be a valid UUID")
-										return nil
+										return validationErr("Please enter a valid website ID. It must be a valid UUID")
 									}
 								}
-								res, _, err := gorequest.
-									New().
-									Get(fmt.Sprintf("%swebsite/%s", apiEndPoint, id)).
-									Set("Authorization", getToken()).
-									End()
 
+								website, err := newClient(c).GetWebsite(id)
 								if err != nil {
-									fmt.Println(err)
-									return nil
-								}
-
-								// Read response body
-								body, er := ioutil.ReadAll(res.Body)
-								if er != nil {
-									return nil
-								}
-
-								// Website Response for /website endpoint
-								var wr WebsiteResponse
-								// first try to Unmarshal expected response
-								er = json.Unmarshal(body, &wr)
-								if er != nil {
-									// if expected response Unmarshalling failed then
-									// try to Unmarshal error
-									er = json.Unmarshal(body, &errorResponse)
-									if er != nil {
-										return nil
-									}
-									fmt.Println("Error: ", errorResponse.Error.Description)
-									return nil
+									return exitErr(err)
 								}
 
-								fmt.Println("-----------------------------------------")
-								fmt.Println("ID: ", wr.Data.ID)
-								fmt.Println("Website URL: ", wr.Data.WebsiteURL)
-								fmt.Println("Website Type: ", wr.Data.WebsiteType)
-								fmt.Println("Website Name: ", wr.Data.WebsiteName)
-
-								return nil
+								return render(c, website, func() {
+									fmt.Println("-----------------------------------------")
+									printWebsite(*website)
+								})
 							},
 						},
 						{
 							Name:  "all",
 							Usage: "Information about all your websites",
 							Action: func(c *cli.Context) error {
-								if getToken() == "" {
-									fmt.Println("API token not found. Please run \"dexecure-cli configure\"")
-									return nil
+								if getToken(c) == "" {
+									return errNoToken
 								}
-								res, _, err := gorequest.
-									New().
-									Get(fmt.Sprintf("%swebsite/", apiEndPoint)).
-									Set("Authorization", getToken()).
-									End()
 
+								websites, err := newClient(c).ListWebsites()
 								if err != nil {
-									fmt.Println(err)
-									return nil
-								}
-
-								// Read response body
-								body, er := ioutil.ReadAll(res.Body)
-								if er != nil {
-									return nil
-								}
-
-								// Website Response for /website endpoint
-								var wr WebsitesResponse
-								// first try to Unmarshal expected response
-								er = json.Unmarshal(body, &wr)
-								if er != nil {
-									// if expected response Unmarshalling failed then
-									// try to Unmarshal error
-									er = json.Unmarshal(body, &errorResponse)
-									if er != nil {
-										return nil
-									}
-									fmt.Println("Error: ", errorResponse.Error.Description)
-									return nil
+									return exitErr(err)
 								}
-								fmt.Println("\nTotal number of websites:", len(wr.Data))
 
-								if wr.Error.Description != "" {
-									fmt.Println(wr.Error.Description)
-								} else {
+								return render(c, websites, func() {
+									fmt.Println("\nTotal number of websites:", len(websites))
 									fmt.Println("")
-									for _, website := range wr.Data {
+									for _, website := range websites {
 										fmt.Println("-----------------------------------------")
-										fmt.Println("ID: ", website.ID)
-										fmt.Println("Website URL: ", website.WebsiteURL)
-										fmt.Println("Website Type: ", website.WebsiteType)
-										fmt.Println("Website Name: ", website.WebsiteName)
+										printWebsite(website)
 									}
 									fmt.Println("-----------------------------------------")
-								}
-
-								return nil
+								})
 							},
 						},
 					},
@@ -302,52 +248,36 @@ func main() {
 				{
 					Name:  "add",
 					Usage: "add a new website",
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "url", Usage: "url of the website"},
+						&cli.StringFlag{Name: "type", Usage: "website type (" + strings.Join(websiteTypes, "|") + ")"},
+						&cli.StringFlag{Name: "name", Usage: "name of the website"},
+					},
 					Action: func(c *cli.Context) error {
-
-						fmt.Print("Enter the url you want to add: ")
-						var websiteURL string
-						fmt.Scanln(&websiteURL)
-						websiteURL = strings.TrimSpace(websiteURL)
-
-						fmt.Print(`Enter website type (magento|wordpress|shopify|none): `)
-						var websiteType string
-						fmt.Scanln(&websiteType)
-						websiteType = strings.TrimSpace(websiteType)
-
-						fmt.Print("Enter website Name: ")
-						var websiteName string
-						scanner := bufio.NewScanner(os.Stdin)
-						if scanner.Scan() {
-							websiteName = scanner.Text()
+						websiteURL, err := flagOrPrompt(c, "url", "Enter the url you want to add: ")
+						if err != nil {
+							return err
 						}
-						websiteName = strings.TrimSpace(websiteName)
 
-						wr := &WebsiteRequest{WebsiteURL: websiteURL, WebsiteType: websiteType, WebsiteName: websiteName}
-						bdy, er := json.Marshal(wr)
-						if er != nil {
-							fmt.Println(er)
-							return nil
+						websiteType, err := flagOrPrompt(c, "type", "Enter website type ("+strings.Join(websiteTypes, "|")+"): ")
+						if err != nil {
+							return err
+						}
+						if !isValidWebsiteType(websiteType) {
+							return validationErr("Please enter a valid website type. It must be one of " + strings.Join(websiteTypes, ", "))
 						}
 
-						res, body, err := gorequest.
-							New().
-							Post(apiEndPoint+"website").
-							Set("Authorization", getToken()).
-							Send(string(bdy)).
-							End()
+						websiteName, err := flagOrPrompt(c, "name", "Enter website Name: ")
 						if err != nil {
-							fmt.Println(err)
-							return nil
+							return err
 						}
 
-						response := parseResponse(body, res)
-						if response.Data != nil {
-							fmt.Println(response.Data["message"])
-						} else {
-							fmt.Println("Error: ", response.Error["description"])
+						wr := client.WebsiteRequest{WebsiteURL: websiteURL, WebsiteType: websiteType, WebsiteName: websiteName}
+						msg, err := newClient(c).CreateWebsite(wr)
+						if err != nil {
+							return exitErr(err)
 						}
-
-						return nil
+						return renderMessage(c, msg)
 					},
 				},
 				{
@@ -355,50 +285,33 @@ func main() {
 					Usage: "Permanently delete a website",
 					Action: func(c *cli.Context) error {
 
-						var id string
-						if c.Args().Len() > 0 {
-							id = c.Args().First()
-						} else {
-							fmt.Print("Enter the id of the website which you want to permanently delete: ")
-							fmt.Scanln(&id)
-							id = strings.TrimSpace(id)
+						id, err := argOrPrompt(c, "Enter the id of the website which you want to permanently delete: ")
+						if err != nil {
+							return err
 						}
 
 						if isValidUUID(id) == false {
-							fmt.Println("Please enter a valid website ID. It must be a valid UUID")
-							return nil
+							return validationErr("Please enter a valid website ID. It must be a valid UUID")
 						}
 
-						fmt.Printf("Going to permanently delete %s website. Are you sure? [Y/n]: ", id)
-
-						var confirm string
-						fmt.Scanln(&confirm)
-
-						if strings.ToLower(confirm) == "y" {
-							res, body, err := gorequest.
-								New().
-								Delete(apiEndPoint+"website/"+id).
-								Set("Authorization", getToken()).
-								End()
-
-							if err != nil {
-								fmt.Println(err)
-								return nil
-							}
-
-							response := parseResponse(body, res)
-
-							if response.Data != nil {
-								fmt.Println(response.Data["message"])
-							} else {
-								fmt.Println("Error: ", response.Error["description"])
-							}
-
-						} else {
+						ok, err := confirm(c, fmt.Sprintf("Going to permanently delete %s website", id), false)
+						if err != nil {
+							return err
+						}
+						if !ok {
 							fmt.Println("Abort mission!")
+							return nil
 						}
 
-						return nil
+						cl := newClient(c)
+						if err := snapshotWebsiteID(c, cl, id); err != nil {
+							return err
+						}
+						msg, err := cl.DeleteWebsite(id)
+						if err != nil {
+							return exitErr(err)
+						}
+						return renderMessage(c, msg)
 					},
 				},
 			},
@@ -411,104 +324,117 @@ func main() {
 				{
 					Name:  "add",
 					Usage: "add a new Dexecure domain",
+					Flags: append([]cli.Flag{
+						&cli.StringFlag{Name: "origin", Usage: "the domain you want to optimize, defaults to the S3 bucket if one is given"},
+						&cli.StringFlag{Name: "website", Usage: "ID of the website the domain belongs to"},
+						presetFlag,
+					}, s3Flags...),
 					Action: func(c *cli.Context) error {
-						if getToken() == "" {
-							fmt.Println("API token not found. Please run \"dexecure-cli configure\"")
-							return nil
+						if getToken(c) == "" {
+							return errNoToken
 						}
-						fmt.Print("Enter the domain you want to optimize: ")
-						var origin string
-						fmt.Scanln(&origin)
-						origin = strings.TrimSpace(origin)
-
-						fmt.Print("Enter Website ID (UUID): ")
-						var websiteID string
-						fmt.Scanln(&websiteID)
-						websiteID = strings.TrimSpace(websiteID)
-
-						if isValidUUID(websiteID) == false {
-							fmt.Println("Please enter a valid website ID. It must be a valid UUID")
-							return nil
+						bucket, err := s3BucketFlags(c)
+						if err != nil {
+							return err
+						}
+						var preset *Preset
+						if c.IsSet("preset") {
+							if preset, err = lookupPreset(c.String("preset")); err != nil {
+								return err
+							}
 						}
 
-						thisDomain := DomainRequest{Origin: origin, WebsiteId: websiteID}
-
-						res, body, err := gorequest.
-							New().
-							Post(apiEndPoint+"distribution").
-							Set("Authorization", getToken()).
-							Send(thisDomain).
-							End()
+						origin := c.String("origin")
+						if bucket != nil && origin == "" {
+							origin = s3Origin(bucket)
+						}
+						if origin == "" {
+							origin, err = flagOrPrompt(c, "origin", "Enter the domain you want to optimize: ")
+							if err != nil {
+								return err
+							}
+						}
 
+						websiteID, err := flagOrPrompt(c, "website", "Enter Website ID (UUID): ")
 						if err != nil {
-							fmt.Println(err)
-							return nil
+							return err
 						}
 
-						response := parseResponse(body, res)
+						if isValidUUID(websiteID) == false {
+							return validationErr("Please enter a valid website ID. It must be a valid UUID")
+						}
 
-						if response.Data != nil {
-							fmt.Println(response.Data["message"])
-						} else {
-							fmt.Println("Error: ", response.Error["description"])
+						thisDomain := client.DomainRequest{Origin: origin, WebsiteId: websiteID}
+						want := client.Distribution{Origin: origin}
+						if bucket != nil {
+							thisDomain.S3BucketIsOrigin = true
+							thisDomain.S3Bucket = bucket
+							want.S3BucketIsOrigin = true
+						}
+						if preset != nil {
+							applyPreset(preset, &want)
+						}
+						checker := newPlanChecker(c)
+						if err := checker.checkDomainLimit(1); err != nil {
+							return err
+						}
+						if err := checker.checkFeatures(&client.Distribution{}, &want); err != nil {
+							return err
+						}
+						cl := newClient(c)
+						msg, err := cl.CreateDistribution(thisDomain)
+						if err != nil {
+							return exitErr(err)
 						}
 
-						return nil
+						if preset != nil {
+							d, err := findDistribution(cl, websiteID, origin)
+							if err != nil {
+								return exitErr(err)
+							}
+							after := copyDistribution(d)
+							applyPreset(preset, &after)
+							if _, err := cl.UpdateDistribution(after); err != nil {
+								return exitErr(err)
+							}
+						}
+						return renderMessage(c, msg)
 					},
 				},
 				{
 					Name:  "rm",
 					Usage: "Permanently delete a domain",
 					Action: func(c *cli.Context) error {
-						if getToken() == "" {
-							fmt.Println("API token not found. Please run \"dexecure-cli configure\"")
-							return nil
+						if getToken(c) == "" {
+							return errNoToken
 						}
-						var id string
-
-						if c.Args().Len() > 0 {
-							id = c.Args().First()
-						} else {
-							fmt.Print("Enter the id of the domain which you want to permanently delete: ")
-							fmt.Scanln(&id)
-							id = strings.TrimSpace(id)
+						id, err := argOrPrompt(c, "Enter the id of the domain which you want to permanently delete: ")
+						if err != nil {
+							return err
 						}
 
 						if isValidUUID(id) == false {
-							fmt.Println("Please enter a valid domain ID. It must be a valid UUID")
-							return nil
+							return validationErr("Please enter a valid domain ID. It must be a valid UUID")
 						}
 
-						fmt.Printf("Going to permanently delete %s domain. Are you sure? [Y/n]: ", id)
-
-						var confirm string
-						fmt.Scanln(&confirm)
-
-						if strings.ToLower(confirm) == "y" {
-
-							res, body, err := gorequest.
-								New().
-								Delete(apiEndPoint+"distribution/"+id).
-								Set("Authorization", getToken()).
-								End()
-
-							if err != nil {
-								fmt.Println(err)
-								return nil
-							}
-
-							response := parseResponse(body, res)
-
-							if response.Data != nil {
-								fmt.Println(response.Data["message"])
-							} else {
-								fmt.Println("Error: ", response.Error["description"])
-							}
-						} else {
+						ok, err := confirm(c, fmt.Sprintf("Going to permanently delete %s domain", id), false)
+						if err != nil {
+							return err
+						}
+						if !ok {
 							fmt.Println("Abort mission!")
+							return nil
 						}
 
-						return nil
+						cl := newClient(c)
+						if err := snapshotDistributionID(c, cl, id); err != nil {
+							return err
+						}
+						msg, err := cl.DeleteDistribution(id)
+						if err != nil {
+							return exitErr(err)
+						}
+						return renderMessage(c, msg)
 					},
 				},
 				{
@@ -519,60 +445,27 @@ func main() {
 							Name:  "website",
 							Usage: "List domains present in a specific website",
 							Action: func(c *cli.Context) error {
-								if getToken() == "" {
-									fmt.Println("API token not found. Please run \"dexecure-cli configure\"")
-									return nil
+								if getToken(c) == "" {
+									return errNoToken
 								}
 
-								id := ""
-								if c.Args().Len() > 0 {
-									id = c.Args().First()
-									id = strings.TrimSpace(id)
-								} else {
-									fmt.Print("Enter a Website ID: ")
-									fmt.Scanln(&id)
+								id, err := argOrPrompt(c, "Enter a Website ID: ")
+								if err != nil {
+									return err
 								}
 								if isValidUUID(id) == false {
-									fmt.Println("Please enter a valid website ID. It must be a valid UUID")
-									return nil
+									return validationErr("Please enter a valid website ID. It must be a valid UUID")
 								}
 
-								res, _, err := gorequest.
-									New().
-									Get(fmt.Sprintf("%sdistribution?websiteId=%s", apiEndPoint, id)).
-									Set("Authorization", getToken()).
-									End()
-
+								domains, err := newClient(c).ListWebsiteDistributions(id)
 								if err != nil {
-									fmt.Println(err)
-									return nil
-								}
-
-								// Read response body
-								body, er := ioutil.ReadAll(res.Body)
-								if er != nil {
-									return nil
-								}
-
-								var dr DomainsResponse
-								er = json.Unmarshal(body, &dr)
-								if er != nil {
-									// if expected response Unmarshalling failed then
-									// try to Unmarshal error
-									er = json.Unmarshal(body, &errorResponse)
-									if er != nil {
-										return nil
-									}
-									fmt.Println("Error: ", errorResponse.Error.Description)
-									return nil
+									return exitErr(err)
 								}
-								fmt.Println("\nDomains in this website:", len(dr.Data.Distributions))
 
-								if dr.Error.Description != "" {
-									fmt.Println(dr.Error.Description)
-								} else {
+								return render(c, domains, func() {
+									fmt.Println("\nDomains in this website:", len(domains))
 									fmt.Println("")
-									for _, domain := range dr.Data.Distributions {
+									for _, domain := range domains {
 										fmt.Println("-----------------------------------------")
 										fmt.Println("Id: ", domain.ID)
 										fmt.Println("Origin: ", domain.Origin)
@@ -580,9 +473,7 @@ func main() {
 										fmt.Println("Type: ", domain.Type)
 									}
 									fmt.Println("-----------------------------------------")
-								}
-
-								return nil
+								})
 							},
 						},
 						{
@@ -590,47 +481,19 @@ func main() {
 							Usage: "Information about your domain(s)",
 							Action: func(c *cli.Context) error {
 
-								if getToken() == "" {
-									fmt.Println("API token not found. Please run \"dexecure-cli configure\"")
-									return nil
+								if getToken(c) == "" {
+									return errNoToken
 								}
 
-								res, _, err := gorequest.
-									New().
-									Get(fmt.Sprintf("%sdistribution/", apiEndPoint)).
-									Set("Authorization", getToken()).
-									End()
-
+								domains, err := newClient(c).ListDistributions()
 								if err != nil {
-									fmt.Println(err)
-									return nil
-								}
-
-								// Read response body
-								body, er := ioutil.ReadAll(res.Body)
-								if er != nil {
-									return nil
+									return exitErr(err)
 								}
 
-								var dr DomainsResponse
-								er = json.Unmarshal(body, &dr)
-								if er != nil || dr.Error.Code != 0 {
-									// if expected response Unmarshalling failed then
-									// try to Unmarshal error
-									er = json.Unmarshal(body, &errorResponse)
-									if er != nil {
-										return nil
-									}
-									fmt.Println("Error: ", errorResponse.Error.Description)
-									return nil
-								}
-								fmt.Println("\nTotal number of domains:", len(dr.Data.Distributions))
-
-								if dr.Error.Description != "" {
-									fmt.Println(dr.Error.Description)
-								} else {
+								return render(c, domains, func() {
+									fmt.Println("\nTotal number of domains:", len(domains))
 									fmt.Println("")
-									for _, domain := range dr.Data.Distributions {
+									for _, domain := range domains {
 										fmt.Println("-----------------------------------------")
 										fmt.Println("Id: ", domain.ID)
 										fmt.Println("Origin: ", domain.Origin)
@@ -639,71 +502,36 @@ func main() {
 										fmt.Println("Type: ", domain.Type)
 									}
 									fmt.Println("-----------------------------------------")
-								}
-
-								return nil
+								})
 							},
 						},
 						{
 							Name:  "id",
 							Usage: "Information about your domain",
 							Action: func(c *cli.Context) error {
-								if getToken() == "" {
-									fmt.Println("API token not found. Please run \"dexecure-cli configure\"")
-									return nil
+								if getToken(c) == "" {
+									return errNoToken
 								}
 
-								id := ""
-								if c.Args().Len() > 0 {
-									id = c.Args().First()
-									id = strings.TrimSpace(id)
-								} else {
-									fmt.Print("Domain ID: ")
-									fmt.Scanln(&id)
+								id, err := argOrPrompt(c, "Domain ID: ")
+								if err != nil {
+									return err
 								}
 								if isValidUUID(id) == false {
-									fmt.Println("Please enter a valid domain ID. It must be a valid UUID")
-									return nil
+									return validationErr("Please enter a valid domain ID. It must be a valid UUID")
 								}
-								res, _, err := gorequest.
-									New().
-									Get(fmt.Sprintf("%sdistribution/%s", apiEndPoint, id)).
-									Set("Authorization", getToken()).
-									End()
 
+								domain, err := newClient(c).GetDistribution(id)
 								if err != nil {
-									fmt.Println(err)
-									return nil
+									return exitErr(err)
 								}
 
-								// Read response body
-								body, er := ioutil.ReadAll(res.Body)
-								if er != nil {
-									return nil
-								}
-								var dr DomainResponse
-								er = json.Unmarshal(body, &dr)
-								if er != nil || dr.Error.Code != 0 {
-									// if expected response Unmarshalling failed then
-									// try to Unmarshal error
-									er = json.Unmarshal(body, &errorResponse)
-									if er != nil {
-										return nil
-									}
-									fmt.Println("Error: ", errorResponse.Error.Description)
-									return nil
-								}
-
-								if dr.Error.Description != "" {
-									fmt.Println(dr.Error.Description)
-								} else {
+								return render(c, domain, func() {
 									fmt.Println("")
 									fmt.Println("-----------------------------------------")
-									printDomain(dr.Data)
+									printDomain(*domain)
 									fmt.Println("-----------------------------------------")
-								}
-
-								return nil
+								})
 							},
 						},
 					},
@@ -711,122 +539,104 @@ func main() {
 				{
 					Name:  "clear",
 					Usage: "Clears the cache for a particular domain",
+					Flags: []cli.Flag{
+						&cli.BoolFlag{Name: "all", Usage: "clear the cache for the entire domain"},
+						&cli.StringFlag{Name: "urls", Usage: "clear the cache for these relative urls (separated by ',')"},
+					},
 					Action: func(c *cli.Context) error {
 
-						if getToken() == "" {
-							fmt.Println("API token not found. Please run \"dexecure-cli configure\"")
-							return nil
+						if getToken(c) == "" {
+							return errNoToken
 						}
 
-						var id string
-
-						if c.Args().Len() > 0 {
-							id = c.Args().First()
-						} else {
-							fmt.Print("Enter the id of the domain whose cache you want to clear: ")
-							fmt.Scanln(&id)
-							id = strings.TrimSpace(id)
+						id, err := argOrPrompt(c, "Enter the id of the domain whose cache you want to clear: ")
+						if err != nil {
+							return err
 						}
 
 						if isValidUUID(id) == false {
-							fmt.Println("Please enter a valid domain ID. It must be a valid UUID")
-							return nil
+							return validationErr("Please enter a valid domain ID. It must be a valid UUID")
 						}
-						fmt.Println("Please choose a option :-")
-						fmt.Println("\t1.Clear cache for entire domain")
-						fmt.Println("\t2.Clear cache by relative urls(*******/asset/script.js)")
-						fmt.Print("How do you want to clean (1/2): ")
-
-						var fc int
-						fmt.Scanln(&fc)
-
-						if fc == 1 {
-							fmt.Printf("Going to purge the cache for %s domain. Are you sure? [Y/n]: ", id)
-							var confirm string
-							fmt.Scanln(&confirm)
-
-							if strings.ToLower(confirm) == "y" {
-
-								url := fmt.Sprintf("%sdistribution/%s/clear", apiEndPoint, id)
-								res, body, err := gorequest.
-									New().
-									Post(url).
-									Set("Authorization", getToken()).
-									Send(`{"url": ["/*"]}`).
-									End()
-
-								if err != nil {
-									fmt.Println(err)
-									return nil
-								}
-
-								response := parseResponse(body, res)
-
-								if response.Data != nil {
-									fmt.Println(response.Data["message"])
-								} else {
-									fmt.Println("Error: ", response.Error["description"])
-								}
 
-							} else {
-								fmt.Println("Abort mission!")
+						var urls []string
+						switch {
+						case c.Bool("all"):
+							urls = []string{"/*"}
+						case c.String("urls") != "":
+							urls = splitURLs(c.String("urls"))
+						case !isTerminal():
+							return validationErr("Missing --all or --urls. One of them is required when stdin is not a terminal")
+						default:
+							fmt.Println("Please choose a option :-")
+							fmt.Println("\t1.Clear cache for entire domain")
+							fmt.Println("\t2.Clear cache by relative urls(*******/asset/script.js)")
+
+							switch prompt("How do you want to clean (1/2): ") {
+							case "1":
+								urls = []string{"/*"}
+							case "2":
+								urls = splitURLs(prompt("Input relative urls(separated by ','): "))
+								fmt.Println("")
+							default:
+								return validationErr("Please choose 1 or 2")
 							}
-						} else if fc == 2 {
-							fmt.Print("Input relative urls(separated by ','): ")
-							var urls string
-							scanner := bufio.NewScanner(os.Stdin)
-							if scanner.Scan() {
-								urls = scanner.Text()
-							}
-							fmt.Printf("\nGoing to purge the cache for %s urls from %s domain. Are you sure? [Y/n]: ", urls, id)
-
-							var confirm string
-							fmt.Scanln(&confirm)
-
-							if strings.ToLower(confirm) == "y" {
-
-								urlSlice := strings.Split(urls, ",")
-								for i := range urlSlice {
-									urlSlice[i] = strings.TrimSpace(urlSlice[i])
-								}
-								urlB, _ := json.Marshal(urlSlice)
-
-								url := fmt.Sprintf("%sdistribution/%s/clear", apiEndPoint, id)
-								res, body, err := gorequest.
-									New().
-									Post(url).
-									Set("Authorization", getToken()).
-									Send(fmt.Sprintf(`{"url": %s}`, string(urlB))).
-									End()
-
-								if err != nil {
-									fmt.Println(err)
-									return nil
-								}
-
-								response := parseResponse(body, res)
+						}
 
-								if response.Data != nil {
-									fmt.Println(response.Data["message"])
-								} else {
-									fmt.Println("Error: ", response.Error["description"])
-								}
-							} else {
-								fmt.Println("Abort mission!")
-							}
+						action := fmt.Sprintf("Going to purge the cache for %s domain", id)
+						if urls[0] != "/*" {
+							action = fmt.Sprintf("Going to purge the cache for %s urls from %s domain", strings.Join(urls, ", "), id)
+						}
+						ok, err := confirm(c, action, true)
+						if err != nil {
+							return err
+						}
+						if !ok {
+							fmt.Println("Abort mission!")
+							return nil
 						}
 
-						return nil
+						msg, err := newClient(c).ClearCache(id, urls)
+						if err != nil {
+							return exitErr(err)
+						}
+						return renderMessage(c, msg)
 					},
 				},
+				domainSetCommand,
+				domainRulesCommand,
+				domainErrorCacheCommand,
+				domainCNameCommand,
+				domainS3Command,
+				domainCloneCommand,
+				domainWaitCommand,
 			},
 		},
+		whoamiCommand,
+		profileCommand,
+		configCommand,
+		exportCommand,
+		planCommand,
+		applyCommand,
+		driftCommand,
+		historyCommand,
+		presetCommand,
+		rollbackCommand,
+	}
+
+	if err := app.Run(reorderArgs(app, os.Args)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitError)
 	}
+}
 
-	app.Run(os.Args)
+func printWebsite(website client.Website) {
+	fmt.Println("ID: ", website.ID)
+	fmt.Println("Website URL: ", website.WebsiteURL)
+	fmt.Println("Website Type: ", website.WebsiteType)
+	fmt.Println("Website Name: ", website.WebsiteName)
 }
 
-func printDomain(dt Data) {
+func printDomain(dt client.Distribution) {
 	fmt.Println("Id: ", dt.ID)
 	fmt.Println("Origin: ", dt.Origin)
 	fmt.Println("Name: ", dt.Name)
@@ -862,8 +672,10 @@ func printDomain(dt Data) {
 	fmt.Println("Zopflipng: ", dt.Zopflipng)
 	fmt.Println("ServerError: ")
 	fmt.Println("*******")
-	for code, se := range dt.ErrorCaching.ServerError {
-		fmt.Println(code, ":", se)
+	for _, r := range errorCacheRows(dt.ErrorCaching) {
+		if r.Status != "4xx" {
+			fmt.Println(r.Status, ":", r.TTL)
+		}
 	}
 	fmt.Println("*******")
 	fmt.Println("ClientError: ")
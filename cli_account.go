@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+var whoamiCommand = &cli.Command{
+	Name:  "whoami",
+	Usage: "Show the account your API token belongs to",
+	Action: func(c *cli.Context) error {
+		if getToken(c) == "" {
+			return errNoToken
+		}
+
+		user, err := newClient(c).GetUser()
+		if err != nil {
+			return exitErr(err)
+		}
+
+		return render(c, user, func() {
+			fmt.Println("Name: ", user.FirstName, user.LastName)
+			fmt.Println("Email: ", user.Email)
+			fmt.Println("Role: ", user.Role)
+			fmt.Println("Verified: ", user.IsVerified)
+			fmt.Println("Enterprise: ", user.IsEnterprise == 1)
+			fmt.Println("Private S3 origins: ", user.FeaturePrivateS3 == 1)
+			fmt.Println("TPO: ", user.FeatureTPO == 1)
+			fmt.Println("Plan: ", user.Plan.Name)
+			fmt.Println("Plan Tier: ", user.Plan.Tier)
+		})
+	},
+}
@@ -0,0 +1,552 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Dexecure/dexecure-cli/client"
+	"github.com/urfave/cli/v2"
+)
+
+// stateFile is the layout of the files written by export and read by apply.
+// Websites are identified by their URL and domains by their origin, so the
+// same file can be applied to any account.
+type stateFile struct {
+	Websites []websiteState `json:"websites"`
+}
+
+type websiteState struct {
+	client.WebsiteRequest
+	Domains []client.Distribution `json:"domains"`
+}
+
+// Kinds of resources and operations of a step.
+const (
+	resourceWebsite = "website"
+	resourceDomain  = "domain"
+
+	opCreate = "create"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+// step is a single change apply makes to the account.
+type step struct {
+	Op       string `json:"op"`
+	Resource string `json:"resource"`
+	// Key is the URL of a website or the origin of a domain.
+	Key string `json:"key"`
+	// ID is the ID of the resource updated or deleted.
+	ID string `json:"id,omitempty"`
+	// Website is the URL of the website a domain belongs to.
+	Website        string                 `json:"website,omitempty"`
+	WebsiteRequest *client.WebsiteRequest `json:"websiteRequest,omitempty"`
+	Domain         *client.Distribution   `json:"domain,omitempty"`
+	Changes        []change               `json:"changes,omitempty"`
+	// Warnings are about rule actions unknown to the CLI, which apply only
+	// sends with --force.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+func websiteKey(url string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(url)), "/")
+}
+
+func domainKey(origin string) string {
+	return normalizeHost(strings.TrimSpace(origin))
+}
+
+// normalizeDistribution replaces empty lists and maps by non-nil ones, so
+// that a domain read from a file compares equal to the same domain returned
+// by the API.
+func normalizeDistribution(d *client.Distribution) {
+	if d.CNames == nil {
+		d.CNames = []string{}
+	}
+	if d.Rules == nil {
+		d.Rules = []client.Rule{}
+	}
+	if d.ErrorCaching.ServerError == nil {
+		d.ErrorCaching.ServerError = map[string]client.Seconds{}
+	}
+}
+
+// clearServerFields clears the fields of d assigned by the server. Type,
+// region and root path can't be chosen when a domain is created, so they
+// count as assigned by the server as well.
+func clearServerFields(d *client.Distribution) {
+	d.ID = ""
+	d.Name = ""
+	d.Status = ""
+	d.WebsiteID = ""
+	d.Type = ""
+	d.Region = ""
+	d.RootPath = ""
+}
+
+// serverFields are the JSON names of the fields cleared by
+// clearServerFields.
+var serverFields = map[string]bool{
+	"id": true, "name": true, "status": true, "websiteId": true, "type": true, "region": true, "rootPath": true,
+}
+
+// createChanges lists every setting sent to create want, the ones left
+// turned off as well, since the API turns on some of them for new domains.
+func createChanges(want *client.Distribution) []change {
+	empty := &client.Distribution{}
+	normalizeDistribution(empty)
+	var changes []change
+	for _, ch := range compareFields(empty, want, true) {
+		if !serverFields[strings.SplitN(ch.Field, ".", 2)[0]] {
+			changes = append(changes, ch)
+		}
+	}
+	return changes
+}
+
+// copyServerFields sets the fields of d assigned by the server to the ones
+// of the live domain, so that they are neither shown as changes nor
+// overwritten by an update.
+func copyServerFields(d, live *client.Distribution) {
+	d.ID, d.Name, d.Status, d.WebsiteID = live.ID, live.Name, live.Status, live.WebsiteID
+	d.Type, d.Region, d.RootPath = live.Type, live.Region, live.RootPath
+}
+
+// fetchDistributions fetches every domain of the account one by one, like
+// drift does. The list of all domains leaves out some of their settings.
+func fetchDistributions(cl *client.Client) ([]client.Distribution, error) {
+	list, err := cl.ListDistributions()
+	if err != nil {
+		return nil, err
+	}
+	dists := make([]client.Distribution, 0, len(list))
+	for _, d := range list {
+		full, err := cl.GetDistribution(d.ID)
+		if err != nil {
+			return nil, err
+		}
+		dists = append(dists, *full)
+	}
+	return dists, nil
+}
+
+// exportState returns every website of the account with its domains.
+func exportState(cl *client.Client) (*stateFile, error) {
+	websites, err := cl.ListWebsites()
+	if err != nil {
+		return nil, err
+	}
+	dists, err := fetchDistributions(cl)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &stateFile{Websites: []websiteState{}}
+	for _, w := range websites {
+		ws := websiteState{
+			WebsiteRequest: client.WebsiteRequest{WebsiteURL: w.WebsiteURL, WebsiteType: w.WebsiteType, WebsiteName: w.WebsiteName},
+			Domains:        []client.Distribution{},
+		}
+		for _, d := range dists {
+			if d.WebsiteID != w.ID {
+				continue
+			}
+			normalizeDistribution(&d)
+			clearServerFields(&d)
+			ws.Domains = append(ws.Domains, d)
+		}
+		state.Websites = append(state.Websites, ws)
+	}
+	return state, nil
+}
+
+// readState reads and validates a file written by export.
+func readState(path string) (*stateFile, error) {
+	var state stateFile
+	if err := readDocument(path, &state); err != nil {
+		return nil, validationErr(err.Error())
+	}
+
+	websites := map[string]bool{}
+	domains := map[string]bool{}
+	for i, ws := range state.Websites {
+		key := websiteKey(ws.WebsiteURL)
+		switch {
+		case key == "":
+			return nil, validationErr(fmt.Sprintf("Website %d in %s has no websiteUrl", i+1, path))
+		case websites[key]:
+			return nil, validationErr(fmt.Sprintf("Website %s is listed twice in %s", ws.WebsiteURL, path))
+		case !isValidWebsiteType(ws.WebsiteType):
+			return nil, validationErr(fmt.Sprintf("Website %s has an invalid websiteType. It must be one of: %s", ws.WebsiteURL, strings.Join(websiteTypes, ", ")))
+		}
+		websites[key] = true
+
+		for j, d := range ws.Domains {
+			key := domainKey(d.Origin)
+			switch {
+			case key == "":
+				return nil, validationErr(fmt.Sprintf("Domain %d of website %s has no origin", j+1, ws.WebsiteURL))
+			case domains[key]:
+				return nil, validationErr(fmt.Sprintf("Domain %s is listed twice in %s", d.Origin, path))
+			}
+			domains[key] = true
+			if err := checkCacheTimes(&d); err != nil {
+				return nil, validationErr(fmt.Sprintf("Domain %s: %v", d.Origin, err))
+			}
+			normalizeDistribution(&state.Websites[i].Domains[j])
+		}
+	}
+	return &state, nil
+}
+
+// account is the live state of an account as returned by the API.
+type account struct {
+	Websites      []client.Website      `json:"websites"`
+	Distributions []client.Distribution `json:"distributions"`
+}
+
+func fetchAccount(cl *client.Client) (*account, error) {
+	websites, err := cl.ListWebsites()
+	if err != nil {
+		return nil, exitErr(err)
+	}
+	dists, err := fetchDistributions(cl)
+	if err != nil {
+		return nil, exitErr(err)
+	}
+	return &account{Websites: websites, Distributions: dists}, nil
+}
+
+// planSteps compares the desired state with the account and returns the
+// steps that make the account match it. With prune, websites and domains
+// missing from desired are deleted.
+func planSteps(live *account, desired *stateFile, prune bool) ([]step, error) {
+	websites, dists := live.Websites, live.Distributions
+
+	liveWebsites := map[string]client.Website{}
+	websiteURLs := map[string]string{}
+	for _, w := range websites {
+		liveWebsites[websiteKey(w.WebsiteURL)] = w
+		websiteURLs[w.ID] = w.WebsiteURL
+	}
+	liveDomains := map[string]client.Distribution{}
+	var liveRules [][]client.Rule
+	for _, d := range dists {
+		liveDomains[domainKey(d.Origin)] = d
+		liveRules = append(liveRules, d.Rules)
+	}
+	knownActions := knownRuleActions(liveRules...)
+
+	var steps []step
+	wanted := map[string]bool{}
+	wantedDomains := map[string]bool{}
+	for _, ws := range desired.Websites {
+		key := websiteKey(ws.WebsiteURL)
+		wanted[key] = true
+
+		lw, exists := liveWebsites[key]
+		if !exists {
+			wr := ws.WebsiteRequest
+			steps = append(steps, step{Op: opCreate, Resource: resourceWebsite, Key: ws.WebsiteURL, WebsiteRequest: &wr,
+				Changes: diffFields(&client.WebsiteRequest{}, &wr)})
+		} else if lw.WebsiteType != ws.WebsiteType || lw.WebsiteName != ws.WebsiteName {
+			return nil, validationErr(fmt.Sprintf("Website %s: the API can't change the type or name of a website. Change them in the file or recreate the website", ws.WebsiteURL))
+		}
+
+		for _, d := range ws.Domains {
+			dkey := domainKey(d.Origin)
+			wantedDomains[dkey] = true
+			want := copyDistribution(&d)
+
+			ld, exists := liveDomains[dkey]
+			warnings, err := validateRules(knownActions, ld.Rules, want.Rules)
+			if err != nil {
+				return nil, validationErr(fmt.Sprintf("Domain %s: %v", d.Origin, err))
+			}
+			for i, w := range warnings {
+				warnings[i] = fmt.Sprintf("domain %s: %s", d.Origin, w)
+			}
+			if !exists {
+				steps = append(steps, step{Op: opCreate, Resource: resourceDomain, Key: d.Origin, Website: ws.WebsiteURL, Domain: &want,
+					Changes: createChanges(&want), Warnings: warnings})
+				continue
+			}
+			if ld.WebsiteID != lw.ID {
+				return nil, validationErr(fmt.Sprintf("Domain %s belongs to website %s, not %s. Domains can't be moved between websites", d.Origin, websiteURLs[ld.WebsiteID], ws.WebsiteURL))
+			}
+
+			normalizeDistribution(&ld)
+			copyServerFields(&want, &ld)
+			if changes := diffFields(&ld, &want); len(changes) > 0 {
+				steps = append(steps, step{Op: opUpdate, Resource: resourceDomain, Key: d.Origin, ID: ld.ID, Website: ws.WebsiteURL, Domain: &want, Changes: changes, Warnings: warnings})
+			}
+		}
+	}
+
+	if !prune {
+		return steps, nil
+	}
+	// Domains are deleted first so that they make room in the plan for the
+	// ones created, websites last once their domains are gone.
+	var deletes []step
+	for _, d := range dists {
+		if !wantedDomains[domainKey(d.Origin)] {
+			deletes = append(deletes, step{Op: opDelete, Resource: resourceDomain, Key: d.Origin, ID: d.ID, Website: websiteURLs[d.WebsiteID],
+				Changes: diffFields(&d, &client.Distribution{})})
+		}
+	}
+	steps = append(deletes, steps...)
+	for _, w := range websites {
+		if !wanted[websiteKey(w.WebsiteURL)] {
+			wr := client.WebsiteRequest{WebsiteURL: w.WebsiteURL, WebsiteType: w.WebsiteType, WebsiteName: w.WebsiteName}
+			steps = append(steps, step{Op: opDelete, Resource: resourceWebsite, Key: w.WebsiteURL, ID: w.ID,
+				Changes: diffFields(&wr, &client.WebsiteRequest{})})
+		}
+	}
+	return steps, nil
+}
+
+var opSigns = map[string]string{opCreate: "+", opUpdate: "~", opDelete: "-"}
+
+func (s step) String() string {
+	if s.Resource == resourceDomain && s.Website != "" {
+		return fmt.Sprintf("%s %s %s (website %s)", opSigns[s.Op], s.Resource, s.Key, s.Website)
+	}
+	return fmt.Sprintf("%s %s %s", opSigns[s.Op], s.Resource, s.Key)
+}
+
+// printSteps prints steps and the fields they change the way terraform
+// does: + for fields set on new resources, - for fields of deleted ones and
+// ~ for changed fields. Warnings follow with !.
+func printSteps(steps []step) {
+	for _, s := range steps {
+		fmt.Println(s)
+		for _, ch := range s.Changes {
+			switch s.Op {
+			case opCreate:
+				fmt.Printf("    + %s: %s\n", ch.Field, ch.After)
+			case opDelete:
+				fmt.Printf("    - %s: %s\n", ch.Field, ch.Before)
+			default:
+				fmt.Printf("    ~ %s: %s -> %s\n", ch.Field, ch.Before, ch.After)
+			}
+		}
+		for _, w := range s.Warnings {
+			fmt.Printf("    ! %s\n", w)
+		}
+	}
+}
+
+// findWebsiteID returns the ID of the website with the given URL.
+func findWebsiteID(cl *client.Client, url string) (string, error) {
+	websites, err := cl.ListWebsites()
+	if err != nil {
+		return "", err
+	}
+	for _, w := range websites {
+		if websiteKey(w.WebsiteURL) == websiteKey(url) {
+			return w.ID, nil
+		}
+	}
+	return "", fmt.Errorf("website %s not found after creating it", url)
+}
+
+// findDistribution returns the domain of a website with the given origin.
+// The API doesn't return the ID of the domains it creates.
+func findDistribution(cl *client.Client, websiteID, origin string) (*client.Distribution, error) {
+	dists, err := cl.ListWebsiteDistributions(websiteID)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range dists {
+		if domainKey(d.Origin) == domainKey(origin) {
+			return &d, nil
+		}
+	}
+	return nil, fmt.Errorf("domain %s not found after creating it", origin)
+}
+
+// createDomain adds want to a website and then changes its settings to the
+// ones of want. It returns the new domain.
+func createDomain(cl *client.Client, websiteID string, want *client.Distribution) (*client.Distribution, error) {
+	dr := client.DomainRequest{WebsiteId: websiteID, Origin: want.Origin}
+	if want.S3BucketIsOrigin {
+		bucket := want.S3Bucket
+		dr.S3BucketIsOrigin = true
+		dr.S3Bucket = &bucket
+	}
+	if _, err := cl.CreateDistribution(dr); err != nil {
+		return nil, err
+	}
+
+	created, err := findDistribution(cl, websiteID, want.Origin)
+	if err != nil {
+		return nil, err
+	}
+	normalizeDistribution(created)
+	after := copyDistribution(want)
+	copyServerFields(&after, created)
+	if len(diffFields(created, &after)) == 0 {
+		return created, nil
+	}
+	if _, err = cl.UpdateDistribution(after); err != nil {
+		return nil, err
+	}
+	return &after, nil
+}
+
+// applyStep makes the change of s to the account.
+func applyStep(cl *client.Client, s step) error {
+	var err error
+	switch {
+	case s.Resource == resourceWebsite && s.Op == opCreate:
+		_, err = cl.CreateWebsite(*s.WebsiteRequest)
+	case s.Resource == resourceWebsite && s.Op == opDelete:
+		_, err = cl.DeleteWebsite(s.ID)
+	case s.Resource == resourceDomain && s.Op == opCreate:
+		var websiteID string
+		if websiteID, err = findWebsiteID(cl, s.Website); err == nil {
+			_, err = createDomain(cl, websiteID, s.Domain)
+		}
+	case s.Resource == resourceDomain && s.Op == opUpdate:
+		_, err = cl.UpdateDistribution(*s.Domain)
+	case s.Resource == resourceDomain && s.Op == opDelete:
+		_, err = cl.DeleteDistribution(s.ID)
+	default:
+		err = fmt.Errorf("unknown step %s %s", s.Op, s.Resource)
+	}
+	return err
+}
+
+// applySteps asks for confirmation and then runs steps in order, stopping
+// at the first failure.
+func applySteps(c *cli.Context, steps []step) error {
+	if len(steps) == 0 {
+		fmt.Println("Nothing to change.")
+		return nil
+	}
+
+	var warnings []string
+	for _, s := range steps {
+		warnings = append(warnings, s.Warnings...)
+	}
+	if err := checkWarnings(c, warnings); err != nil {
+		return err
+	}
+	if err := newPlanChecker(c).checkSteps(steps); err != nil {
+		return err
+	}
+
+	text := c.String("output") == "text" && c.String("format") == ""
+	if text {
+		printSteps(steps)
+	}
+	ok, err := confirm(c, fmt.Sprintf("Going to make %d changes", len(steps)), true)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("Abort mission!")
+		return nil
+	}
+
+	cl := newClient(c)
+	for i, s := range steps {
+		if err := snapshotStep(c, cl, s); err != nil {
+			return err
+		}
+		if err := applyStep(cl, s); err != nil {
+			return cli.Exit(fmt.Sprintf("Error: %s %s %s: %v (%d of %d changes made)", s.Op, s.Resource, s.Key, err, i, len(steps)), exitCode(err))
+		}
+		if text {
+			fmt.Printf("%s %s %s\n", map[string]string{opCreate: "Created", opUpdate: "Updated", opDelete: "Deleted"}[s.Op], s.Resource, s.Key)
+		}
+	}
+	return render(c, steps, func() {
+		fmt.Printf("%d changes made.\n", len(steps))
+	})
+}
+
+var exportCommand = &cli.Command{
+	Name:  "export",
+	Usage: "Write all websites and domains to a YAML or JSON file that apply can read",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "file", Aliases: []string{"f"}, Usage: "file to write to, defaults to stdout. A .json file is written as JSON"},
+	},
+	Action: func(c *cli.Context) error {
+		if getToken(c) == "" {
+			return errNoToken
+		}
+
+		asJSON := c.String("output") == "json" || strings.HasSuffix(c.String("file"), ".json")
+		if c.String("format") != "" || (c.String("output") != "text" && c.String("output") != "yaml" && c.String("output") != "json") {
+			return validationErr("export only writes YAML or JSON")
+		}
+
+		state, err := exportState(newClient(c))
+		if err != nil {
+			return exitErr(err)
+		}
+
+		w := os.Stdout
+		if c.String("file") != "" {
+			if w, err = os.Create(c.String("file")); err != nil {
+				return cli.Exit("Error: "+err.Error(), exitError)
+			}
+			defer w.Close()
+		}
+		if asJSON {
+			err = writeJSON(w, state)
+		} else {
+			err = writeYAML(w, state)
+		}
+		if err != nil {
+			return cli.Exit("Error: "+err.Error(), exitError)
+		}
+		return nil
+	},
+}
+
+var applyCommand = &cli.Command{
+	Name:      "apply",
+	Usage:     "Create and update websites and domains until the account matches a file written by export, or run a saved plan",
+	ArgsUsage: "[<plan-file>]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "file", Aliases: []string{"f"}, Usage: "YAML or JSON file describing the websites and domains"},
+		&cli.BoolFlag{Name: "prune", Usage: "also delete websites and domains missing from the file"},
+		forceFlag,
+	},
+	Action: func(c *cli.Context) error {
+		if getToken(c) == "" {
+			return errNoToken
+		}
+		if c.Args().Present() {
+			if c.IsSet("file") || c.IsSet("prune") {
+				return validationErr("A saved plan can't be combined with --file or --prune")
+			}
+			steps, err := readPlan(c, c.Args().First())
+			if err != nil {
+				return err
+			}
+			return applySteps(c, steps)
+		}
+		if c.String("file") == "" {
+			return validationErr("Please enter a file with --file, or a plan written by plan --out")
+		}
+
+		desired, err := readState(c.String("file"))
+		if err != nil {
+			return err
+		}
+		live, err := fetchAccount(newClient(c))
+		if err != nil {
+			return err
+		}
+		steps, err := planSteps(live, desired, c.Bool("prune"))
+		if err != nil {
+			return err
+		}
+		return applySteps(c, steps)
+	},
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Dexecure/dexecure-cli/client"
+)
+
+const (
+	testWebsiteID = "11111111-1111-4111-8111-111111111111"
+	testDomainID  = "22222222-2222-4222-8222-222222222222"
+)
+
+func testAccount() *account {
+	return &account{
+		Websites: []client.Website{{ID: testWebsiteID, WebsiteURL: "https://example.com", WebsiteType: "none", WebsiteName: "example"}},
+		Distributions: []client.Distribution{{
+			ID: testDomainID, Origin: "example.com", Name: "d123.dexecure.net", Status: "deployed", WebsiteID: testWebsiteID,
+			Type: "x", Region: "us", RootPath: "/",
+			JsEnabled: true, DefaultCacheTime: client.Day,
+			Rules: []client.Rule{{Pattern: "/legacy/*", Actions: []string{"webp-only"}}},
+		}},
+	}
+}
+
+// createdDomainFields are the settings listed by every step that creates a
+// domain, whether they are turned on or not.
+const createdDomainFields = "origin,CNames,jsEnabled,cssEnabled,imageEnabled,SVGEnabled,fontEnabled,proxyEnabled," +
+	"cacheControlImmutable,GIFEnabled,defaultCacheTime,rules,autoResize,autoRotate,heifEnabled,textDetection," +
+	"faceDetection,zopflipng,errorCaching.clientError.default,linkCanonical,s3BucketIsOrigin,s3Bucket.name,s3Bucket.region"
+
+// testState returns the desired state matching testAccount, as a
+// hand-written file without the fields assigned by the server.
+func testState() *stateFile {
+	state := &stateFile{Websites: []websiteState{{
+		WebsiteRequest: client.WebsiteRequest{WebsiteURL: "https://example.com/", WebsiteType: "none", WebsiteName: "example"},
+		Domains: []client.Distribution{{
+			Origin: "example.com", JsEnabled: true, DefaultCacheTime: client.Day,
+			Rules: []client.Rule{{Pattern: "/legacy/*", Actions: []string{"webp-only"}}},
+		}},
+	}}}
+	return state
+}
+
+// summarize describes steps as "op resource key field,field".
+func summarize(steps []step) []string {
+	out := []string{}
+	for _, s := range steps {
+		var fields []string
+		for _, ch := range s.Changes {
+			fields = append(fields, ch.Field)
+		}
+		out = append(out, strings.TrimSpace(s.Op+" "+s.Resource+" "+s.Key+" "+strings.Join(fields, ",")))
+	}
+	return out
+}
+
+func TestPlanSteps(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(live *account, desired *stateFile)
+		prune    bool
+		want     []string
+		warnings []string
+		err      string
+	}{
+		{
+			name: "no changes",
+			want: []string{},
+		},
+		{
+			name: "update a setting",
+			change: func(live *account, desired *stateFile) {
+				desired.Websites[0].Domains[0].ImageEnabled = true
+			},
+			want: []string{"update domain example.com imageEnabled"},
+		},
+		{
+			name: "add a rule next to one with an action the API accepted",
+			change: func(live *account, desired *stateFile) {
+				d := &desired.Websites[0].Domains[0]
+				d.Rules = append(d.Rules, client.Rule{Pattern: "/img/*", Actions: []string{"image"}})
+			},
+			want: []string{"update domain example.com rules"},
+		},
+		{
+			name: "warn about a new rule with an unknown action",
+			change: func(live *account, desired *stateFile) {
+				d := &desired.Websites[0].Domains[0]
+				d.Rules = append(d.Rules, client.Rule{Pattern: "/img/*", Actions: []string{"bogus"}})
+			},
+			want:     []string{"update domain example.com rules"},
+			warnings: []string{`domain example.com: unknown action "bogus" in rule "/img/*"`},
+		},
+		{
+			name: "reject a new rule without actions",
+			change: func(live *account, desired *stateFile) {
+				d := &desired.Websites[0].Domains[0]
+				d.Rules = append(d.Rules, client.Rule{Pattern: "/img/*"})
+			},
+			err: "needs at least one action",
+		},
+		{
+			name: "create a website and a domain",
+			change: func(live *account, desired *stateFile) {
+				desired.Websites = append(desired.Websites, websiteState{
+					WebsiteRequest: client.WebsiteRequest{WebsiteURL: "https://shop.example.com", WebsiteType: "shopify", WebsiteName: "shop"},
+					Domains:        []client.Distribution{{Origin: "shop.example.com", ImageEnabled: true}},
+				})
+			},
+			want: []string{
+				"create website https://shop.example.com websiteUrl,websiteType,websiteName",
+				"create domain shop.example.com " + createdDomainFields,
+			},
+		},
+		{
+			name: "leave missing resources alone without prune",
+			change: func(live *account, desired *stateFile) {
+				desired.Websites = nil
+			},
+			want: []string{},
+		},
+		{
+			name: "delete missing resources with prune",
+			change: func(live *account, desired *stateFile) {
+				desired.Websites = nil
+			},
+			prune: true,
+			want: []string{
+				"delete domain example.com id,origin,name,type,status,websiteId,region,rootPath,jsEnabled,defaultCacheTime,rules",
+				"delete website https://example.com websiteUrl,websiteType,websiteName",
+			},
+		},
+		{
+			name: "delete domains with prune before creating their replacements",
+			change: func(live *account, desired *stateFile) {
+				desired.Websites[0].Domains[0].Origin = "www.example.com"
+			},
+			prune: true,
+			want: []string{
+				"delete domain example.com id,origin,name,type,status,websiteId,region,rootPath,jsEnabled,defaultCacheTime,rules",
+				"create domain www.example.com " + createdDomainFields,
+			},
+		},
+		{
+			name: "refuse to change the type of a website",
+			change: func(live *account, desired *stateFile) {
+				desired.Websites[0].WebsiteType = "magento"
+			},
+			err: "can't change the type or name",
+		},
+		{
+			name: "refuse to move a domain between websites",
+			change: func(live *account, desired *stateFile) {
+				live.Websites = append(live.Websites, client.Website{ID: "33333333-3333-4333-8333-333333333333", WebsiteURL: "https://other.com", WebsiteType: "none"})
+				live.Distributions[0].WebsiteID = "33333333-3333-4333-8333-333333333333"
+			},
+			err: "can't be moved between websites",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			live, desired := testAccount(), testState()
+			if tt.change != nil {
+				tt.change(live, desired)
+			}
+			for i := range desired.Websites {
+				for j := range desired.Websites[i].Domains {
+					normalizeDistribution(&desired.Websites[i].Domains[j])
+				}
+			}
+
+			steps, err := planSteps(live, desired, tt.prune)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := summarize(steps); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("steps = %q, want %q", got, tt.want)
+			}
+			var warnings []string
+			for _, s := range steps {
+				warnings = append(warnings, s.Warnings...)
+			}
+			if !reflect.DeepEqual(warnings, tt.warnings) {
+				t.Errorf("warnings = %q, want %q", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestPlanStepsKeepsServerFields(t *testing.T) {
+	desired := testState()
+	desired.Websites[0].Domains[0].CSSEnabled = true
+	normalizeDistribution(&desired.Websites[0].Domains[0])
+
+	steps, err := planSteps(testAccount(), desired, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 1 {
+		t.Fatalf("got %d steps, want 1", len(steps))
+	}
+	d := steps[0].Domain
+	if d.ID != testDomainID || d.Type != "x" || d.Region != "us" || d.RootPath != "/" || d.WebsiteID != testWebsiteID {
+		t.Errorf("update would send id %q, type %q, region %q, rootPath %q, websiteId %q instead of the live values",
+			d.ID, d.Type, d.Region, d.RootPath, d.WebsiteID)
+	}
+}
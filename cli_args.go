@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// reorderArgs moves positional arguments behind the flags of the command
+// they belong to, so that "domain set <id> --image=false" parses the same as
+// "domain set --image=false <id>". urfave/cli stops parsing flags at the
+// first positional argument otherwise.
+func reorderArgs(app *cli.App, args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+
+	out := []string{args[0]}
+	var positional []string
+	flags := app.Flags
+	commands := app.Commands
+
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			positional = append(positional, args[i:]...)
+			i = len(args)
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			out = append(out, arg)
+			if !strings.Contains(arg, "=") && takesValue(flags, strings.TrimLeft(arg, "-")) && i+1 < len(args) {
+				i++
+				out = append(out, args[i])
+			}
+		default:
+			if cmd := findCommand(commands, arg); cmd != nil && len(positional) == 0 {
+				out = append(out, arg)
+				flags = cmd.Flags
+				commands = cmd.Subcommands
+				continue
+			}
+			positional = append(positional, arg)
+		}
+	}
+	return append(out, positional...)
+}
+
+func findCommand(commands []*cli.Command, name string) *cli.Command {
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd
+		}
+		for _, alias := range cmd.Aliases {
+			if alias == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+// takesValue reports whether the flag name is followed by a value, i.e.
+// isn't a boolean flag.
+func takesValue(flags []cli.Flag, name string) bool {
+	for _, f := range flags {
+		for _, n := range f.Names() {
+			if n != name {
+				continue
+			}
+			_, isBool := f.(*cli.BoolFlag)
+			return !isBool
+		}
+	}
+	return false
+}
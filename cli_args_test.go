@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestReorderArgs(t *testing.T) {
+	app := &cli.App{
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}},
+			&cli.BoolFlag{Name: "yes", Aliases: []string{"y"}},
+		},
+		Commands: []*cli.Command{
+			{
+				Name:    "domain",
+				Aliases: []string{"d"},
+				Subcommands: []*cli.Command{
+					{
+						Name: "set",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{Name: "image"},
+							&cli.StringFlag{Name: "default-cache-time"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		args string
+		want string
+	}{
+		{"", ""},
+		{"dx", "dx"},
+		{"dx domain set id", "dx domain set id"},
+		{"dx domain set id --image", "dx domain set --image id"},
+		{"dx domain set id --image=false", "dx domain set --image=false id"},
+		{"dx domain set id --default-cache-time 1d --image", "dx domain set --default-cache-time 1d --image id"},
+		{"dx -o json domain set id --image", "dx -o json domain set --image id"},
+		{"dx --yes d set id --image", "dx --yes d set --image id"},
+		{"dx domain set id -- --image", "dx domain set id -- --image"},
+		{"dx domain set set --image", "dx domain set --image set"},
+		{"dx domain set - --image", "dx domain set --image -"},
+		{"dx domain set --default-cache-time", "dx domain set --default-cache-time"},
+		{"dx unknown id --flag value", "dx --flag unknown id value"},
+	}
+	for _, tt := range tests {
+		got := reorderArgs(app, strings.Fields(tt.args))
+		if want := strings.Fields(tt.want); !reflect.DeepEqual(got, want) && !(len(got) == 0 && len(want) == 0) {
+			t.Errorf("reorderArgs(%q) = %q, want %q", tt.args, got, want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Dexecure/dexecure-cli/client"
+	"github.com/urfave/cli/v2"
+)
+
+// cloneExclusion is a group of settings "domain clone --exclude" can leave
+// out. clear resets them to what a new domain starts with.
+type cloneExclusion struct {
+	name  string
+	clear func(d *client.Distribution)
+}
+
+var cloneExclusions = []cloneExclusion{
+	{"cnames", func(d *client.Distribution) { d.CNames = []string{} }},
+	{"s3", func(d *client.Distribution) {
+		d.S3BucketIsOrigin = false
+		d.S3Bucket = client.S3Bucket{}
+	}},
+	{"rules", func(d *client.Distribution) { d.Rules = []client.Rule{} }},
+}
+
+func cloneExclusionNames() []string {
+	var names []string
+	for _, e := range cloneExclusions {
+		names = append(names, e.name)
+	}
+	return names
+}
+
+// cloneSettings returns the settings of src for a new domain with the given
+// origin, without the excluded groups.
+func cloneSettings(src *client.Distribution, origin string, exclude []string) (client.Distribution, error) {
+	d := copyDistribution(src)
+	clearServerFields(&d)
+	normalizeDistribution(&d)
+	d.Origin = origin
+
+	for _, name := range exclude {
+		found := false
+		for _, e := range cloneExclusions {
+			if e.name == name {
+				e.clear(&d)
+				found = true
+			}
+		}
+		if !found {
+			return d, fmt.Errorf("unknown --exclude %q. It must be one of: %s", name, strings.Join(cloneExclusionNames(), ", "))
+		}
+	}
+	return d, nil
+}
+
+var domainCloneCommand = &cli.Command{
+	Name:      "clone",
+	Usage:     "Create a new domain with the settings of an existing one",
+	ArgsUsage: "<source-domain-id>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "origin", Usage: "the domain you want to optimize", Required: true},
+		&cli.StringFlag{Name: "website", Usage: "ID of the website the new domain belongs to", Required: true},
+		&cli.StringFlag{Name: "exclude", Usage: "comma separated settings not to copy (" + strings.Join(cloneExclusionNames(), "|") + ")"},
+	},
+	Action: func(c *cli.Context) error {
+		websiteID := c.String("website")
+		if isValidUUID(websiteID) == false {
+			return validationErr("Please enter a valid website ID. It must be a valid UUID")
+		}
+
+		src, err := distributionArg(c)
+		if err != nil {
+			return err
+		}
+		want, err := cloneSettings(src, strings.TrimSpace(c.String("origin")), splitActions(c.String("exclude")))
+		if err != nil {
+			return validationErr(err.Error())
+		}
+
+		cl := newClient(c)
+		website, err := cl.GetWebsite(websiteID)
+		if err != nil {
+			return exitErr(err)
+		}
+		checker := newPlanChecker(c)
+		if err := checker.checkDomainLimit(1); err != nil {
+			return err
+		}
+		if err := checker.checkFeatures(&client.Distribution{}, &want); err != nil {
+			return err
+		}
+
+		preview := step{Op: opCreate, Resource: resourceDomain, Key: want.Origin, Website: website.WebsiteURL,
+			Domain: &want, Changes: createChanges(&want)}
+		if c.String("output") == "text" && c.String("format") == "" {
+			fmt.Printf("Cloning %s domain (%s):\n", src.ID, src.Origin)
+			printSteps([]step{preview})
+		}
+		ok, err := confirm(c, fmt.Sprintf("Going to create %s domain", want.Origin), true)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Abort mission!")
+			return nil
+		}
+
+		created, err := createDomain(cl, websiteID, &want)
+		if err != nil {
+			return exitErr(err)
+		}
+		return render(c, created, func() {
+			fmt.Printf("Created %s domain with ID %s\n", created.Origin, created.ID)
+		})
+	},
+}
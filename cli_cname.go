@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// States reported by "domain cname verify".
+const (
+	cnameOK          = "ok"
+	cnameMissing     = "missing"
+	cnameWrongTarget = "wrong-target"
+	cnamePropagating = "propagating"
+	cnameError       = "error"
+)
+
+var hostnameRegexp = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z]{2,63}$`)
+
+// cnameResolver looks up the canonical name of a host. *net.Resolver
+// implements it; tests and --dns-server plug in other servers.
+type cnameResolver interface {
+	LookupCNAME(ctx context.Context, host string) (string, error)
+}
+
+// dnsServerResolver returns a resolver that sends every query to server,
+// given as host:port.
+func dnsServerResolver(server string) cnameResolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// cnameRow is how "domain cname ls" lists a CNAME.
+type cnameRow struct {
+	CName string `json:"cname"`
+}
+
+// cnameCheck is the result of verifying one CNAME.
+type cnameCheck struct {
+	CName   string   `json:"cname"`
+	Target  string   `json:"target"`
+	State   string   `json:"state"`
+	Answers []string `json:"answers"`
+}
+
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// lookupCName asks r for the canonical name of host, waiting at most
+// timeout.
+func lookupCName(r cnameResolver, host string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return r.LookupCNAME(ctx, host)
+}
+
+// verifyCNames checks every cname against target with verifyCName. The
+// canonical name of target is looked up once per resolver.
+func verifyCNames(resolvers []cnameResolver, cnames []string, target string, timeout time.Duration) []cnameCheck {
+	canonicals := make([]string, len(resolvers))
+	for i, r := range resolvers {
+		canonical, err := lookupCName(r, target, timeout)
+		if err != nil {
+			canonical = target
+		}
+		canonicals[i] = canonical
+	}
+
+	checks := []cnameCheck{}
+	for _, cname := range cnames {
+		checks = append(checks, verifyCName(resolvers, canonicals, cname, target, timeout))
+	}
+	return checks
+}
+
+// verifyCName asks every resolver where cname points to and compares the
+// answers with target. When only some resolvers already see the right
+// target the record is still propagating.
+//
+// LookupCNAME follows the whole chain of CNAMEs and returns the last name.
+// The Dexecure hostname is often a CNAME itself, so an answer matching
+// canonicals, the canonical name of target each resolver sees, is correct
+// as well.
+func verifyCName(resolvers []cnameResolver, canonicals []string, cname, target string, timeout time.Duration) cnameCheck {
+	check := cnameCheck{CName: cname, Target: target}
+
+	var correct, missing, failed int
+	for i, r := range resolvers {
+		answer, err := lookupCName(r, cname, timeout)
+		var dnsErr *net.DNSError
+		switch {
+		case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+			missing++
+			check.Answers = append(check.Answers, "not found")
+		case err != nil:
+			failed++
+			check.Answers = append(check.Answers, err.Error())
+		case normalizeHost(answer) == normalizeHost(target) || normalizeHost(answer) == normalizeHost(canonicals[i]):
+			correct++
+			check.Answers = append(check.Answers, normalizeHost(answer))
+		default:
+			check.Answers = append(check.Answers, normalizeHost(answer))
+		}
+	}
+
+	switch {
+	case correct == len(resolvers):
+		check.State = cnameOK
+	case correct > 0:
+		check.State = cnamePropagating
+	case failed > 0:
+		check.State = cnameError
+	case missing == len(resolvers):
+		check.State = cnameMissing
+	default:
+		check.State = cnameWrongTarget
+	}
+	return check
+}
+
+// hostnameArg returns the hostname given as second argument.
+func hostnameArg(c *cli.Context) (string, error) {
+	if c.Args().Len() < 2 {
+		return "", validationErr("Please enter the CNAME, e.g. assets.example.com")
+	}
+	host := normalizeHost(c.Args().Get(1))
+	if !hostnameRegexp.MatchString(host) {
+		return "", validationErr(fmt.Sprintf("%q is not a valid hostname", host))
+	}
+	return host, nil
+}
+
+var domainCNameCommand = &cli.Command{
+	Name:  "cname",
+	Usage: "manage the custom CNAMEs of a domain",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "ls",
+			Usage:     "List the CNAMEs of a domain",
+			ArgsUsage: "<domain-id>",
+			Action: func(c *cli.Context) error {
+				d, err := distributionArg(c)
+				if err != nil {
+					return err
+				}
+
+				rows := []cnameRow{}
+				for _, cname := range d.CNames {
+					rows = append(rows, cnameRow{CName: cname})
+				}
+				return render(c, rows, func() {
+					for _, r := range rows {
+						fmt.Println(r.CName)
+					}
+				})
+			},
+		},
+		{
+			Name:      "add",
+			Usage:     "Add a CNAME to a domain",
+			ArgsUsage: "<domain-id> <cname>",
+			Action: func(c *cli.Context) error {
+				host, err := hostnameArg(c)
+				if err != nil {
+					return err
+				}
+
+				d, err := distributionArg(c)
+				if err != nil {
+					return err
+				}
+				for _, cname := range d.CNames {
+					if normalizeHost(cname) == host {
+						return validationErr(fmt.Sprintf("%s is already a CNAME of this domain", host))
+					}
+				}
+
+				after := copyDistribution(d)
+				after.CNames = append(after.CNames, host)
+				return updateDistribution(c, d, &after)
+			},
+		},
+		{
+			Name:      "rm",
+			Usage:     "Remove a CNAME from a domain",
+			ArgsUsage: "<domain-id> <cname>",
+			Action: func(c *cli.Context) error {
+				host, err := hostnameArg(c)
+				if err != nil {
+					return err
+				}
+
+				d, err := distributionArg(c)
+				if err != nil {
+					return err
+				}
+
+				after := copyDistribution(d)
+				after.CNames = []string{}
+				for _, cname := range d.CNames {
+					if normalizeHost(cname) != host {
+						after.CNames = append(after.CNames, cname)
+					}
+				}
+				if len(after.CNames) == len(d.CNames) {
+					return cli.Exit(fmt.Sprintf("%s is not a CNAME of this domain", host), exitNotFound)
+				}
+				return updateDistribution(c, d, &after)
+			},
+		},
+		{
+			Name:      "verify",
+			Usage:     "Check that the CNAMEs of a domain point at it in DNS",
+			ArgsUsage: "<domain-id>",
+			Flags: []cli.Flag{
+				&cli.StringSliceFlag{Name: "dns-server", Usage: "DNS server (host:port) to ask instead of the system resolver, can be repeated"},
+				&cli.DurationFlag{Name: "dns-timeout", Value: 5 * time.Second, Usage: "time limit for each lookup"},
+			},
+			Action: func(c *cli.Context) error {
+				d, err := distributionArg(c)
+				if err != nil {
+					return err
+				}
+
+				resolvers := []cnameResolver{net.DefaultResolver}
+				if servers := c.StringSlice("dns-server"); len(servers) > 0 {
+					resolvers = nil
+					for _, server := range servers {
+						resolvers = append(resolvers, dnsServerResolver(server))
+					}
+				}
+
+				checks := verifyCNames(resolvers, d.CNames, d.Name, c.Duration("dns-timeout"))
+				failing := 0
+				for _, check := range checks {
+					if check.State != cnameOK {
+						failing++
+					}
+				}
+
+				err = render(c, checks, func() {
+					for _, check := range checks {
+						fmt.Printf("%s: %s (expected %s, got %s)\n", check.CName, check.State, check.Target, strings.Join(check.Answers, ", "))
+					}
+				})
+				if err != nil {
+					return err
+				}
+				if failing > 0 {
+					return cli.Exit(fmt.Sprintf("%d of %d CNAMEs don't point at %s yet", failing, len(checks), d.Name), exitError)
+				}
+				return nil
+			},
+		},
+	},
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeResolver answers from a map of host to canonical name. Hosts missing
+// from the map aren't found.
+type fakeResolver struct {
+	names map[string]string
+	err   error
+}
+
+func (r fakeResolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		return "", errors.New("lookup without a time limit")
+	}
+	if r.err != nil {
+		return "", r.err
+	}
+	if name, ok := r.names[host]; ok {
+		return name, nil
+	}
+	return "", &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestVerifyCName(t *testing.T) {
+	const cname, target = "cdn.example.com", "d123.dexecure.net"
+
+	pointing := fakeResolver{names: map[string]string{cname: target + ".", target: target + "."}}
+	// The Dexecure hostname is a CNAME of a load balancer, so the lookup of
+	// the whole chain ends there.
+	chained := fakeResolver{names: map[string]string{cname: "lb.dexecure.net.", target: "lb.dexecure.net."}}
+	elsewhere := fakeResolver{names: map[string]string{cname: "old.cdn.net.", target: target + "."}}
+	missing := fakeResolver{names: map[string]string{target: target + "."}}
+	broken := fakeResolver{err: errors.New("i/o timeout")}
+
+	tests := []struct {
+		name      string
+		resolvers []cnameResolver
+		state     string
+		answers   []string
+	}{
+		{"ok", []cnameResolver{pointing, pointing}, cnameOK, []string{target, target}},
+		{"ok through a chain", []cnameResolver{chained}, cnameOK, []string{"lb.dexecure.net"}},
+		{"missing", []cnameResolver{missing, missing}, cnameMissing, []string{"not found", "not found"}},
+		{"wrong target", []cnameResolver{elsewhere}, cnameWrongTarget, []string{"old.cdn.net"}},
+		{"propagating", []cnameResolver{pointing, elsewhere}, cnamePropagating, []string{target, "old.cdn.net"}},
+		{"propagating while missing", []cnameResolver{missing, pointing}, cnamePropagating, []string{"not found", target}},
+		{"error", []cnameResolver{broken, missing}, cnameError, []string{"i/o timeout", "not found"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := verifyCNames(tt.resolvers, []string{cname}, target, time.Second)
+			if len(checks) != 1 {
+				t.Fatalf("got %d checks, want 1", len(checks))
+			}
+			check := checks[0]
+			if check.State != tt.state {
+				t.Errorf("state = %s, want %s", check.State, tt.state)
+			}
+			if !reflect.DeepEqual(check.Answers, tt.answers) {
+				t.Errorf("answers = %q, want %q", check.Answers, tt.answers)
+			}
+		})
+	}
+}
+
+// countingResolver counts the lookups of every host and fails the ones
+// whose time limit ran out.
+type countingResolver struct {
+	fakeResolver
+	lookups map[string]int
+}
+
+func (r countingResolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	r.lookups[host]++
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	time.Sleep(10 * time.Millisecond)
+	return r.fakeResolver.LookupCNAME(ctx, host)
+}
+
+func TestVerifyCNamesLooksUpEachNameOnce(t *testing.T) {
+	const target = "d123.dexecure.net"
+	cnames := []string{"a.example.com", "b.example.com", "c.example.com", "d.example.com"}
+	names := map[string]string{target: "lb.dexecure.net."}
+	for _, cname := range cnames {
+		names[cname] = "lb.dexecure.net."
+	}
+	r := countingResolver{fakeResolver: fakeResolver{names: names}, lookups: map[string]int{}}
+
+	// Every lookup takes 10ms. With a limit shared by all of them, the
+	// later ones would fail.
+	checks := verifyCNames([]cnameResolver{r, r}, cnames, target, 30*time.Millisecond)
+	for _, check := range checks {
+		if check.State != cnameOK {
+			t.Errorf("%s: state = %s, answers %q, want %s", check.CName, check.State, check.Answers, cnameOK)
+		}
+	}
+	if r.lookups[target] != 2 {
+		t.Errorf("looked up the target %d times, want once per resolver", r.lookups[target])
+	}
+	for _, cname := range cnames {
+		if r.lookups[cname] != 2 {
+			t.Errorf("looked up %s %d times, want once per resolver", cname, r.lookups[cname])
+		}
+	}
+}
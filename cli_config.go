@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/Dexecure/dexecure-cli/client"
+	"github.com/tucnak/store"
+	"github.com/urfave/cli/v2"
+)
+
+const defaultProfile = "default"
+
+var profileFlag = &cli.StringFlag{
+	Name:  "profile",
+	Usage: "name of the credential profile to use [$DEXECURE_PROFILE]",
+}
+
+var endpointFlag = &cli.StringFlag{
+	Name:  "endpoint",
+	Usage: "base url of the Dexecure API [$DEXECURE_API_ENDPOINT]",
+}
+
+var settingFlags = []cli.Flag{
+	profileFlag,
+	endpointFlag,
+	&cli.StringFlag{
+		Name:  "token",
+		Usage: "API token to use instead of the one saved in the profile [$DEXECURE_API_TOKEN]",
+	},
+	&cli.IntFlag{
+		Name:  "max-retries",
+		Value: client.DefaultMaxRetries,
+		Usage: "how many times a failed API call is retried",
+	},
+	&cli.DurationFlag{
+		Name:  "timeout",
+		Value: time.Minute,
+		Usage: "time limit for a single API call, 0 for none",
+	},
+}
+
+// setting is a configuration value together with where it came from.
+type setting struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// settings is the configuration a command runs with.
+type settings struct {
+	Profile  setting
+	Token    setting
+	Endpoint setting
+}
+
+// lookupSetting resolves a setting from, in order of precedence, its flag,
+// its environment variable and the profile, falling back to def.
+func lookupSetting(c *cli.Context, name, envVar, fromProfile, profile, def string) setting {
+	if value := c.String(name); value != "" {
+		return setting{Name: name, Value: value, Source: "--" + name + " flag"}
+	}
+	if value := os.Getenv(envVar); value != "" {
+		return setting{Name: name, Value: value, Source: envVar}
+	}
+	if fromProfile != "" {
+		return setting{Name: name, Value: fromProfile, Source: "profile " + profile}
+	}
+	return setting{Name: name, Value: def, Source: "default"}
+}
+
+func resolveSettings(c *cli.Context) (*settings, error) {
+	config, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	name := profileSetting(c, config)
+	profile := config.Profiles[name.Value]
+	if profile == nil {
+		profile = &Profile{}
+	}
+
+	return &settings{
+		Profile:  name,
+		Token:    lookupSetting(c, "token", "DEXECURE_API_TOKEN", profile.Token, name.Value, ""),
+		Endpoint: lookupSetting(c, "endpoint", "DEXECURE_API_ENDPOINT", profile.Endpoint, name.Value, client.DefaultEndpoint),
+	}, nil
+}
+
+// loadConfig reads config.json. A token saved by older versions of the CLI
+// in token.json is moved into the default profile the first time.
+func loadConfig() (*Config, error) {
+	var config Config
+	if configExists("config.json") {
+		if err := store.Load("config.json", &config); err != nil {
+			return nil, err
+		}
+	}
+	if config.Profiles == nil {
+		config.Profiles = map[string]*Profile{}
+	}
+
+	if len(config.Profiles) == 0 && configExists("token.json") {
+		var tokenSettings TokenSettings
+		store.Load("token.json", &tokenSettings)
+		if tokenSettings.Token != "" {
+			config.Profiles[defaultProfile] = &Profile{Token: tokenSettings.Token}
+			config.CurrentProfile = defaultProfile
+			if err := saveConfig(&config); err != nil {
+				return nil, err
+			}
+			savePrivate("token.json", &TokenSettings{})
+		}
+	}
+
+	return &config, nil
+}
+
+func saveConfig(config *Config) error {
+	return savePrivate("config.json", config)
+}
+
+// configExists reports whether the file name exists in the config
+// directory. store.Load creates missing files, readable by everyone.
+func configExists(name string) bool {
+	_, err := os.Stat(filepath.Join(configDir(), name))
+	return err == nil
+}
+
+// savePrivate writes v as JSON to the file name in the config directory,
+// readable and writable only by the user. store.Save makes files readable
+// by everyone, which is no place for API tokens.
+func savePrivate(name string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(configDir(), name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, append(b, '\n'), 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of files that already exist.
+	return os.Chmod(path, 0600)
+}
+
+// configDir returns the directory store keeps the files of the CLI in.
+func configDir() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "dexecure")
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "dexecure")
+	}
+	return filepath.Join(os.Getenv("HOME"), ".config", "dexecure")
+}
+
+// profileSetting returns the profile selected with --profile or
+// DEXECURE_PROFILE, falling back to the one chosen with "profile use".
+func profileSetting(c *cli.Context, config *Config) setting {
+	name := lookupSetting(c, "profile", "DEXECURE_PROFILE", "", "", defaultProfile)
+	if name.Source == "default" && config.CurrentProfile != "" {
+		name = setting{Name: "profile", Value: config.CurrentProfile, Source: "profile use"}
+	}
+	return name
+}
+
+func profileName(c *cli.Context, config *Config) string {
+	return profileSetting(c, config).Value
+}
+
+// maskToken hides all but the last four characters of a token.
+func maskToken(token string) string {
+	if len(token) <= 4 {
+		return token
+	}
+	return fmt.Sprintf("****%s", token[len(token)-4:])
+}
+
+var profileCommand = &cli.Command{
+	Name:  "profile",
+	Usage: "manage credential profiles for multiple Dexecure accounts",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "ls",
+			Usage: "List your profiles",
+			Action: func(c *cli.Context) error {
+				config, err := loadConfig()
+				if err != nil {
+					return exitErr(err)
+				}
+
+				current := profileName(c, config)
+				var profiles []profileInfo
+				for name, profile := range config.Profiles {
+					profiles = append(profiles, profileInfo{
+						Name:    name,
+						Current: name == current,
+						Token:   maskToken(profile.Token),
+					})
+				}
+				sort.Slice(profiles, func(i, j int) bool {
+					return profiles[i].Name < profiles[j].Name
+				})
+
+				return render(c, profiles, func() {
+					for _, profile := range profiles {
+						marker := " "
+						if profile.Current {
+							marker = "*"
+						}
+						fmt.Println(marker, profile.Name, profile.Token)
+					}
+				})
+			},
+		},
+		{
+			Name:      "use",
+			Usage:     "Make a profile the one used by default",
+			ArgsUsage: "<profile>",
+			Action: func(c *cli.Context) error {
+				if c.Args().Len() == 0 {
+					return validationErr("Please enter the name of the profile to use")
+				}
+				name := c.Args().First()
+
+				config, err := loadConfig()
+				if err != nil {
+					return exitErr(err)
+				}
+				if _, ok := config.Profiles[name]; !ok {
+					return cli.Exit(fmt.Sprintf("Profile %q not found. Run \"dexecure-cli configure --profile %s\" to add it", name, name), exitNotFound)
+				}
+
+				config.CurrentProfile = name
+				if err := saveConfig(config); err != nil {
+					return exitErr(err)
+				}
+				fmt.Printf("Now using profile %s.\n", name)
+				return nil
+			},
+		},
+		{
+			Name:      "rm",
+			Usage:     "Remove a profile and its API token",
+			ArgsUsage: "<profile>",
+			Action: func(c *cli.Context) error {
+				if c.Args().Len() == 0 {
+					return validationErr("Please enter the name of the profile to remove")
+				}
+				name := c.Args().First()
+
+				config, err := loadConfig()
+				if err != nil {
+					return exitErr(err)
+				}
+				if _, ok := config.Profiles[name]; !ok {
+					return cli.Exit(fmt.Sprintf("Profile %q not found", name), exitNotFound)
+				}
+
+				ok, err := confirm(c, fmt.Sprintf("Going to remove the %s profile", name), false)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					fmt.Println("Abort mission!")
+					return nil
+				}
+
+				delete(config.Profiles, name)
+				if config.CurrentProfile == name {
+					config.CurrentProfile = ""
+				}
+				if err := saveConfig(config); err != nil {
+					return exitErr(err)
+				}
+				fmt.Printf("Profile %s removed.\n", name)
+				return nil
+			},
+		},
+	},
+}
+
+var configCommand = &cli.Command{
+	Name:  "config",
+	Usage: "inspect the configuration of the CLI",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "view",
+			Usage: "Show every setting and where its value comes from",
+			Action: func(c *cli.Context) error {
+				s, err := resolveSettings(c)
+				if err != nil {
+					return exitErr(err)
+				}
+
+				token := s.Token
+				token.Value = maskToken(token.Value)
+				view := []setting{s.Profile, token, s.Endpoint}
+
+				return render(c, view, func() {
+					for _, st := range view {
+						fmt.Printf("%s: %s (from %s)\n", st.Name, st.Value, st.Source)
+					}
+				})
+			},
+		},
+	},
+}
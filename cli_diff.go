@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// change is a field whose value differs between two versions of a resource.
+type change struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// diffFields compares every field of before and after, which must be structs
+// of the same type, and returns the ones that differ. Nested objects are
+// compared key by key and reported as dot paths, the same way drift reports
+// them. Values are compared as they are shown in table output.
+func diffFields(before, after interface{}) []change {
+	return compareFields(before, after, false)
+}
+
+// compareFields compares before and after like diffFields. With all, the
+// fields after has in common with before are returned as well.
+func compareFields(before, after interface{}, all bool) []change {
+	bf, af := map[string]interface{}{}, map[string]interface{}{}
+	flatten("", fieldTree(reflect.ValueOf(before)), bf)
+	flatten("", fieldTree(reflect.ValueOf(after)), af)
+
+	t := reflect.Indirect(reflect.ValueOf(before)).Type()
+	order := map[string]int{}
+	for i, f := range tableFields(t, nil) {
+		order[jsonName(t.FieldByIndex(f))] = i
+	}
+
+	names := map[string]bool{}
+	for name := range bf {
+		names[name] = true
+	}
+	for name := range af {
+		names[name] = true
+	}
+	var changes []change
+	for name := range names {
+		b, a := leafText(bf, name), leafText(af, name)
+		if b != a || (all && a != "(none)") {
+			changes = append(changes, change{Field: name, Before: b, After: a})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		ti := order[strings.SplitN(changes[i].Field, ".", 2)[0]]
+		tj := order[strings.SplitN(changes[j].Field, ".", 2)[0]]
+		if ti != tj {
+			return ti < tj
+		}
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// fieldTree converts v into nested maps keyed by JSON name, the shape v has
+// when decoded from JSON, with every other value formatted by cell.
+func fieldTree(v reflect.Value) interface{} {
+	v = reflect.Indirect(v)
+	if !v.IsValid() {
+		return "null"
+	}
+	if _, ok := v.Interface().(fmt.Stringer); ok {
+		return cell(v)
+	}
+	switch {
+	case v.Kind() == reflect.Struct:
+		tree := map[string]interface{}{}
+		for _, f := range tableFields(v.Type(), nil) {
+			tree[jsonName(v.Type().FieldByIndex(f))] = fieldTree(v.FieldByIndex(f))
+		}
+		return tree
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		tree := map[string]interface{}{}
+		for _, k := range v.MapKeys() {
+			tree[k.String()] = fieldTree(v.MapIndex(k))
+		}
+		return tree
+	}
+	return cell(v)
+}
+
+// leafText returns the value of field name in fields, which flatten filled
+// from a fieldTree. Empty objects and missing fields have no value.
+func leafText(fields map[string]interface{}, name string) string {
+	if s, ok := fields[name].(string); ok {
+		return s
+	}
+	return "(none)"
+}
+
+func printChanges(w io.Writer, changes []change) {
+	for _, ch := range changes {
+		fmt.Fprintf(w, "  ~ %s: %s -> %s\n", ch.Field, ch.Before, ch.After)
+	}
+}
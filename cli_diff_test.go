@@ -0,0 +1,36 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Dexecure/dexecure-cli/client"
+)
+
+func TestDiffFields(t *testing.T) {
+	before := &client.Distribution{Origin: "example.com", DefaultCacheTime: client.Day}
+	before.ErrorCaching.ServerError = map[string]client.Seconds{"500": client.Minute, "502": client.Minute}
+	after := &client.Distribution{Origin: "example.com", DefaultCacheTime: 2 * client.Day, ImageEnabled: true}
+	after.ErrorCaching.ServerError = map[string]client.Seconds{"500": client.Hour}
+	after.ErrorCaching.ClientError.Default = 30
+
+	want := []change{
+		{Field: "imageEnabled", Before: "false", After: "true"},
+		{Field: "defaultCacheTime", Before: "1d", After: "2d"},
+		{Field: "errorCaching.clientError.default", Before: "0s", After: "30s"},
+		{Field: "errorCaching.serverError.500", Before: "1m", After: "1h"},
+		{Field: "errorCaching.serverError.502", Before: "1m", After: "(none)"},
+	}
+	if got := diffFields(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffFields() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiffFieldsIgnoresEmptyObjects(t *testing.T) {
+	before := &client.Distribution{}
+	after := &client.Distribution{}
+	after.ErrorCaching.ServerError = map[string]client.Seconds{}
+	if got := diffFields(before, after); len(got) != 0 {
+		t.Errorf("diffFields() = %+v, want no changes", got)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Dexecure/dexecure-cli/client"
+	"github.com/urfave/cli/v2"
+)
+
+// toggle is an on/off optimization setting of a distribution.
+type toggle struct {
+	flag  string
+	usage string
+	field func(d *client.Distribution) *bool
+}
+
+var distributionToggles = []toggle{
+	{"js", "optimize JavaScript", func(d *client.Distribution) *bool { return &d.JsEnabled }},
+	{"css", "optimize CSS", func(d *client.Distribution) *bool { return &d.CSSEnabled }},
+	{"image", "optimize images", func(d *client.Distribution) *bool { return &d.ImageEnabled }},
+	{"svg", "optimize SVGs", func(d *client.Distribution) *bool { return &d.SVGEnabled }},
+	{"font", "optimize fonts", func(d *client.Distribution) *bool { return &d.FontEnabled }},
+	{"gif", "optimize GIFs", func(d *client.Distribution) *bool { return &d.GIFEnabled }},
+	{"proxy", "proxy requests to the origin", func(d *client.Distribution) *bool { return &d.ProxyEnabled }},
+	{"cache-control-immutable", "send Cache-Control: immutable", func(d *client.Distribution) *bool { return &d.CacheControlImmutable }},
+	{"auto-resize", "resize images automatically", func(d *client.Distribution) *bool { return &d.AutoResize }},
+	{"auto-rotate", "rotate images automatically", func(d *client.Distribution) *bool { return &d.AutoRotate }},
+	{"heif", "serve HEIF images", func(d *client.Distribution) *bool { return &d.HeifEnabled }},
+	{"text-detection", "detect text in images", func(d *client.Distribution) *bool { return &d.TextDetection }},
+	{"face-detection", "detect faces in images", func(d *client.Distribution) *bool { return &d.FaceDetection }},
+	{"zopflipng", "compress PNGs with zopflipng", func(d *client.Distribution) *bool { return &d.Zopflipng }},
+	{"link-canonical", "send a canonical Link header", func(d *client.Distribution) *bool { return &d.LinkCanonical }},
+}
+
+// checkSeconds checks that a cache time isn't negative. The API doesn't
+// document a maximum, so longer ones are left for it to judge.
+func checkSeconds(s client.Seconds) error {
+	if s < 0 {
+		return fmt.Errorf("%s must not be negative", s)
+	}
+	return nil
+}
+
+// secondsFlag parses the cache time given as flag name.
+func secondsFlag(c *cli.Context, name string) (client.Seconds, error) {
+	s, err := client.ParseSeconds(c.String(name))
+	if err != nil {
+		return 0, validationErr(fmt.Sprintf("Please enter a valid --%s: %v", name, err))
+	}
+	return s, nil
+}
+
+// checkCacheTimes checks that no cache time of d is negative.
+func checkCacheTimes(d *client.Distribution) error {
+	if err := checkSeconds(d.DefaultCacheTime); err != nil {
+		return fmt.Errorf("defaultCacheTime: %v", err)
+	}
+	for status, ttl := range d.ErrorCaching.ServerError {
+		if err := checkSeconds(ttl); err != nil {
+			return fmt.Errorf("errorCaching.serverError.%s: %v", status, err)
+		}
+	}
+	if err := checkSeconds(d.ErrorCaching.ClientError.Default); err != nil {
+		return fmt.Errorf("errorCaching.clientError.default: %v", err)
+	}
+	return nil
+}
+
+func domainSetFlags() []cli.Flag {
+	flags := []cli.Flag{
+		presetFlag,
+		&cli.StringFlag{Name: "default-cache-time", Usage: "default cache time, e.g. 3600, 12h, 7d or 1y"},
+	}
+	for _, t := range distributionToggles {
+		flags = append(flags, &cli.BoolFlag{Name: t.flag, Usage: t.usage + " (--" + t.flag + "=false to disable)"})
+	}
+	return flags
+}
+
+// applySettings changes the settings of d that were given as flags. Flags
+// take precedence over the settings of --preset.
+func applySettings(c *cli.Context, d *client.Distribution) error {
+	if c.IsSet("preset") {
+		p, err := lookupPreset(c.String("preset"))
+		if err != nil {
+			return err
+		}
+		applyPreset(p, d)
+	}
+
+	for _, t := range distributionToggles {
+		if c.IsSet(t.flag) {
+			*t.field(d) = c.Bool(t.flag)
+		}
+	}
+
+	if c.IsSet("default-cache-time") {
+		seconds, err := secondsFlag(c, "default-cache-time")
+		if err != nil {
+			return err
+		}
+		d.DefaultCacheTime = seconds
+	}
+	return nil
+}
+
+// copyDistribution returns a deep copy of d, which can be changed without
+// touching d.
+func copyDistribution(d *client.Distribution) client.Distribution {
+	var cp client.Distribution
+	b, _ := json.Marshal(d)
+	json.Unmarshal(b, &cp)
+	return cp
+}
+
+// updateDistribution shows the changes between before and after, asks for
+// confirmation and saves after.
+func updateDistribution(c *cli.Context, before, after *client.Distribution) error {
+	changes := diffFields(before, after)
+	if len(changes) == 0 {
+		fmt.Println("Nothing to change.")
+		return nil
+	}
+	if err := newPlanChecker(c).checkFeatures(before, after); err != nil {
+		return err
+	}
+
+	if c.String("output") == "text" && c.String("format") == "" {
+		fmt.Printf("Changes to %s domain:\n", after.ID)
+		printChanges(os.Stdout, changes)
+	}
+
+	ok, err := confirm(c, fmt.Sprintf("Going to update %s domain", after.ID), true)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("Abort mission!")
+		return nil
+	}
+
+	if err := snapshotDistribution(c, before); err != nil {
+		return err
+	}
+	msg, err := newClient(c).UpdateDistribution(*after)
+	if err != nil {
+		return exitErr(err)
+	}
+	return render(c, changes, func() {
+		fmt.Println(msg)
+	})
+}
+
+var domainSetCommand = &cli.Command{
+	Name:      "set",
+	Usage:     "Change the optimization settings of a domain",
+	ArgsUsage: "<domain-id>",
+	Flags:     domainSetFlags(),
+	Action: func(c *cli.Context) error {
+		before, err := distributionArg(c)
+		if err != nil {
+			return err
+		}
+
+		after := copyDistribution(before)
+		if err := applySettings(c, &after); err != nil {
+			return err
+		}
+		return updateDistribution(c, before, &after)
+	},
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dexecure/dexecure-cli/client"
+	"github.com/urfave/cli/v2"
+)
+
+// snapshot is the baseline drift compares against: every distribution of
+// the account as returned by the API, keyed by ID.
+type snapshot struct {
+	CreatedAt     time.Time                  `json:"createdAt"`
+	Distributions map[string]json.RawMessage `json:"distributions"`
+}
+
+// Kinds of drift.
+const (
+	driftAdded   = "added"
+	driftRemoved = "removed"
+	driftChanged = "changed"
+)
+
+// fieldDrift is a field of a distribution that differs from the baseline.
+// Nested objects are compared per key, e.g. errorCaching.serverError.503.
+type fieldDrift struct {
+	Field  string      `json:"field"`
+	Change string      `json:"change"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+	// Unknown is set for fields this version of the CLI doesn't know.
+	Unknown bool `json:"unknown"`
+}
+
+type distributionDrift struct {
+	ID     string       `json:"id"`
+	Origin string       `json:"origin"`
+	Change string       `json:"change"`
+	Fields []fieldDrift `json:"fields"`
+	// UnknownFields are the fields sent by the API this version of the CLI
+	// doesn't know, whether they changed or not.
+	UnknownFields []string `json:"unknownFields"`
+}
+
+type driftReport struct {
+	Baseline      string              `json:"baseline"`
+	BaselineTime  time.Time           `json:"baselineTime"`
+	CheckedAt     time.Time           `json:"checkedAt"`
+	Drifted       bool                `json:"drifted"`
+	Distributions []distributionDrift `json:"distributions"`
+}
+
+// knownFields returns the JSON names of the fields of Distribution.
+func knownFields() map[string]bool {
+	t := reflect.TypeOf(client.Distribution{})
+	known := map[string]bool{}
+	for _, f := range tableFields(t, nil) {
+		known[jsonName(t.FieldByIndex(f))] = true
+	}
+	return known
+}
+
+// takeSnapshot fetches every distribution the same way "domain ls all" and
+// "domain ls id" do, keeping the JSON sent by the API.
+func takeSnapshot(cl *client.Client) (*snapshot, error) {
+	dists, err := cl.ListDistributions()
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &snapshot{CreatedAt: time.Now().UTC(), Distributions: map[string]json.RawMessage{}}
+	for _, d := range dists {
+		raw, err := cl.GetDistributionJSON(d.ID)
+		if err != nil {
+			return nil, err
+		}
+		snap.Distributions[d.ID] = raw
+	}
+	return snap, nil
+}
+
+// flatten adds the fields of v to out. Non-empty objects are flattened into
+// one field per key.
+func flatten(prefix string, v interface{}, out map[string]interface{}) {
+	m, ok := v.(map[string]interface{})
+	if !ok || (len(m) == 0 && prefix != "") {
+		out[prefix] = v
+		return
+	}
+	for k, value := range m {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		flatten(k, value, out)
+	}
+}
+
+func decodeFields(raw json.RawMessage) (map[string]interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	flatten("", v, fields)
+	return fields, nil
+}
+
+// compareDistribution compares the fields of a distribution in the baseline
+// and now. Either may be nil if the distribution was added or removed.
+func compareDistribution(id string, before, after json.RawMessage, ignore map[string]bool, known map[string]bool) (*distributionDrift, error) {
+	bf, af := map[string]interface{}{}, map[string]interface{}{}
+	var err error
+	if before != nil {
+		if bf, err = decodeFields(before); err != nil {
+			return nil, fmt.Errorf("invalid baseline of %s: %v", id, err)
+		}
+	}
+	if after != nil {
+		if af, err = decodeFields(after); err != nil {
+			return nil, err
+		}
+	}
+
+	dd := &distributionDrift{ID: id, Change: driftChanged, Fields: []fieldDrift{}, UnknownFields: []string{}}
+	switch {
+	case before == nil:
+		dd.Change = driftAdded
+	case after == nil:
+		dd.Change = driftRemoved
+	}
+	if origin, ok := af["origin"].(string); ok {
+		dd.Origin = origin
+	} else if origin, ok := bf["origin"].(string); ok {
+		dd.Origin = origin
+	}
+
+	names := map[string]bool{}
+	for name := range bf {
+		names[name] = true
+	}
+	for name := range af {
+		names[name] = true
+	}
+	unknown := map[string]bool{}
+	for name := range names {
+		top := strings.SplitN(name, ".", 2)[0]
+		if !known[top] && af[name] != nil {
+			unknown[top] = true
+		}
+		if ignore[top] || ignore[name] {
+			continue
+		}
+
+		b, inBefore := bf[name]
+		a, inAfter := af[name]
+		fd := fieldDrift{Field: name, Before: b, After: a, Unknown: !known[top]}
+		switch {
+		case before == nil || after == nil:
+			continue
+		case !inBefore:
+			fd.Change = driftAdded
+		case !inAfter:
+			fd.Change = driftRemoved
+		case !reflect.DeepEqual(a, b):
+			fd.Change = driftChanged
+		default:
+			continue
+		}
+		dd.Fields = append(dd.Fields, fd)
+	}
+	sort.Slice(dd.Fields, func(i, j int) bool { return dd.Fields[i].Field < dd.Fields[j].Field })
+	for name := range unknown {
+		dd.UnknownFields = append(dd.UnknownFields, name)
+	}
+	sort.Strings(dd.UnknownFields)
+	return dd, nil
+}
+
+// compareSnapshots returns the drift of every distribution that was added,
+// removed or changed since the baseline, or that has fields unknown to the
+// CLI.
+func compareSnapshots(baseline, current *snapshot, ignore map[string]bool) ([]distributionDrift, error) {
+	ids := map[string]bool{}
+	for id := range baseline.Distributions {
+		ids[id] = true
+	}
+	for id := range current.Distributions {
+		ids[id] = true
+	}
+
+	known := knownFields()
+	drifts := []distributionDrift{}
+	for id := range ids {
+		dd, err := compareDistribution(id, baseline.Distributions[id], current.Distributions[id], ignore, known)
+		if err != nil {
+			return nil, err
+		}
+		if dd.Change == driftChanged && len(dd.Fields) == 0 && len(dd.UnknownFields) == 0 {
+			continue
+		}
+		drifts = append(drifts, *dd)
+	}
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].Origin+drifts[i].ID < drifts[j].Origin+drifts[j].ID })
+	return drifts, nil
+}
+
+func (dd distributionDrift) drifted() bool {
+	return dd.Change != driftChanged || len(dd.Fields) > 0
+}
+
+func jsonValue(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func printDrift(report driftReport) {
+	for _, dd := range report.Distributions {
+		switch dd.Change {
+		case driftAdded:
+			fmt.Printf("+ domain %s (%s) is not in the baseline\n", dd.Origin, dd.ID)
+		case driftRemoved:
+			fmt.Printf("- domain %s (%s) was deleted\n", dd.Origin, dd.ID)
+		default:
+			fmt.Printf("~ domain %s (%s)\n", dd.Origin, dd.ID)
+		}
+		for _, fd := range dd.Fields {
+			note := ""
+			if fd.Unknown {
+				note = " (unknown to this CLI)"
+			}
+			switch fd.Change {
+			case driftAdded:
+				fmt.Printf("    + %s: %s%s\n", fd.Field, jsonValue(fd.After), note)
+			case driftRemoved:
+				fmt.Printf("    - %s: %s%s\n", fd.Field, jsonValue(fd.Before), note)
+			default:
+				fmt.Printf("    ~ %s: %s -> %s%s\n", fd.Field, jsonValue(fd.Before), jsonValue(fd.After), note)
+			}
+		}
+		if len(dd.UnknownFields) > 0 {
+			fmt.Printf("    fields unknown to this CLI: %s\n", strings.Join(dd.UnknownFields, ", "))
+		}
+	}
+	if report.Drifted {
+		fmt.Printf("The domains drifted from the baseline taken at %s.\n", report.BaselineTime.Format(time.RFC3339))
+	} else {
+		fmt.Printf("No drift since the baseline taken at %s.\n", report.BaselineTime.Format(time.RFC3339))
+	}
+}
+
+func writeSnapshot(path string, snap *snapshot) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return writeJSON(f, snap)
+}
+
+var driftCommand = &cli.Command{
+	Name:  "drift",
+	Usage: "Report changes made to the domains since a baseline snapshot",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "baseline", Usage: "JSON snapshot of the domains to compare against", Required: true},
+		&cli.BoolFlag{Name: "update", Usage: "save the current domains as the new baseline, creating it if needed"},
+		&cli.StringSliceFlag{Name: "ignore", Value: cli.NewStringSlice("status"), Usage: "fields that are expected to change"},
+	},
+	Action: func(c *cli.Context) error {
+		if getToken(c) == "" {
+			return errNoToken
+		}
+
+		var baseline snapshot
+		path := c.String("baseline")
+		_, statErr := os.Stat(path)
+		if os.IsNotExist(statErr) && !c.Bool("update") {
+			return validationErr(fmt.Sprintf("Baseline %s not found. Create it with --update", path))
+		}
+
+		current, err := takeSnapshot(newClient(c))
+		if err != nil {
+			return exitErr(err)
+		}
+
+		if os.IsNotExist(statErr) {
+			if err := writeSnapshot(path, current); err != nil {
+				return cli.Exit("Error: "+err.Error(), exitError)
+			}
+			fmt.Fprintf(os.Stderr, "Saved %d domains as baseline to %s\n", len(current.Distributions), path)
+			return nil
+		}
+		if err := readDocument(path, &baseline); err != nil {
+			return validationErr(err.Error())
+		}
+		if baseline.Distributions == nil {
+			return validationErr(fmt.Sprintf("%s is not a baseline written by \"dexecure-cli drift --update\"", path))
+		}
+
+		ignore := map[string]bool{}
+		for _, name := range c.StringSlice("ignore") {
+			ignore[name] = true
+		}
+		drifts, err := compareSnapshots(&baseline, current, ignore)
+		if err != nil {
+			return validationErr(err.Error())
+		}
+
+		report := driftReport{
+			Baseline:      path,
+			BaselineTime:  baseline.CreatedAt,
+			CheckedAt:     current.CreatedAt,
+			Distributions: drifts,
+		}
+		for _, dd := range drifts {
+			report.Drifted = report.Drifted || dd.drifted()
+		}
+		if err := render(c, report, func() { printDrift(report) }); err != nil {
+			return err
+		}
+
+		if c.Bool("update") {
+			if err := writeSnapshot(path, current); err != nil {
+				return cli.Exit("Error: "+err.Error(), exitError)
+			}
+			fmt.Fprintf(os.Stderr, "Updated the baseline %s\n", path)
+		}
+		if report.Drifted {
+			return cli.Exit("", exitChanges)
+		}
+		return nil
+	},
+}
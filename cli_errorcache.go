@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/Dexecure/dexecure-cli/client"
+	"github.com/urfave/cli/v2"
+)
+
+// errorCacheRow is how error caching is listed by "domain error-cache ls".
+type errorCacheRow struct {
+	Status string         `json:"status"`
+	TTL    client.Seconds `json:"ttl"`
+}
+
+// errorCacheRows lists the cache time of every server error status code in
+// order, followed by the default for client errors.
+func errorCacheRows(ec client.ErrorCaching) []errorCacheRow {
+	rows := []errorCacheRow{}
+	for status, ttl := range ec.ServerError {
+		rows = append(rows, errorCacheRow{Status: status, TTL: ttl})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Status < rows[j].Status
+	})
+	return append(rows, errorCacheRow{Status: "4xx", TTL: ec.ClientError.Default})
+}
+
+// serverErrorStatus validates the --status flag, which must be a 5xx code.
+func serverErrorStatus(c *cli.Context) (string, error) {
+	status := c.Int("status")
+	if status < 500 || status > 599 {
+		return "", validationErr(fmt.Sprintf("Please enter a valid --status. Error caching is set per 5xx status code, got %d", status))
+	}
+	return strconv.Itoa(status), nil
+}
+
+var domainErrorCacheCommand = &cli.Command{
+	Name:  "error-cache",
+	Usage: "manage how long error responses of the origin are cached",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "ls",
+			Usage:     "List the cache times of error responses",
+			ArgsUsage: "<domain-id>",
+			Action: func(c *cli.Context) error {
+				d, err := distributionArg(c)
+				if err != nil {
+					return err
+				}
+
+				rows := errorCacheRows(d.ErrorCaching)
+				return render(c, rows, func() {
+					for _, r := range rows {
+						fmt.Printf("%s: %s\n", r.Status, r.TTL)
+					}
+				})
+			},
+		},
+		{
+			Name:      "set",
+			Usage:     "Set the cache time of a server error or the default for client errors",
+			ArgsUsage: "<domain-id>",
+			Flags: []cli.Flag{
+				&cli.IntFlag{Name: "status", Usage: "5xx status code to set the cache time of"},
+				&cli.StringFlag{Name: "ttl", Usage: "cache time for --status, e.g. 10s or 5m, at most 1d"},
+				&cli.StringFlag{Name: "client-default", Usage: "cache time of 4xx responses, e.g. 60s, at most 1d"},
+			},
+			Action: func(c *cli.Context) error {
+				if !c.IsSet("status") && !c.IsSet("client-default") {
+					return validationErr("Missing --status and --ttl, or --client-default")
+				}
+				if c.IsSet("status") != c.IsSet("ttl") {
+					return validationErr("--status and --ttl must be given together")
+				}
+
+				d, err := distributionArg(c)
+				if err != nil {
+					return err
+				}
+				after := copyDistribution(d)
+
+				if c.IsSet("status") {
+					status, err := serverErrorStatus(c)
+					if err != nil {
+						return err
+					}
+					ttl, err := secondsFlag(c, "ttl")
+					if err != nil {
+						return err
+					}
+					if after.ErrorCaching.ServerError == nil {
+						after.ErrorCaching.ServerError = map[string]client.Seconds{}
+					}
+					after.ErrorCaching.ServerError[status] = ttl
+				}
+
+				if c.IsSet("client-default") {
+					ttl, err := secondsFlag(c, "client-default")
+					if err != nil {
+						return err
+					}
+					after.ErrorCaching.ClientError.Default = ttl
+				}
+
+				return updateDistribution(c, d, &after)
+			},
+		},
+		{
+			Name:      "unset",
+			Usage:     "Stop caching a server error status code",
+			ArgsUsage: "<domain-id>",
+			Flags: []cli.Flag{
+				&cli.IntFlag{Name: "status", Usage: "5xx status code", Required: true},
+			},
+			Action: func(c *cli.Context) error {
+				status, err := serverErrorStatus(c)
+				if err != nil {
+					return err
+				}
+
+				d, err := distributionArg(c)
+				if err != nil {
+					return err
+				}
+				if _, ok := d.ErrorCaching.ServerError[status]; !ok {
+					return cli.Exit(fmt.Sprintf("No cache time set for %s responses", status), exitNotFound)
+				}
+
+				after := copyDistribution(d)
+				delete(after.ErrorCaching.ServerError, status)
+				return updateDistribution(c, d, &after)
+			},
+		},
+	},
+}
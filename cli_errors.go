@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Dexecure/dexecure-cli/client"
+	"github.com/urfave/cli/v2"
+)
+
+// Exit codes returned by the CLI. Scripts can rely on these to tell why a
+// command failed.
+const (
+	exitError      = 1
+	exitValidation = 2
+	exitAuth       = 3
+	exitNotFound   = 4
+	exitRateLimit  = 5
+	exitNetwork    = 6
+	exitAborted    = 7
+	// exitChanges is returned by plan when the account differs from the
+	// desired state.
+	exitChanges = 8
+	// exitTimeout is returned by domain wait when the domain didn't reach
+	// the status in time.
+	exitTimeout = 9
+	// exitPlan is returned when a change needs a feature or more domains
+	// than the plan of the account includes.
+	exitPlan = 10
+)
+
+var errNoToken = cli.Exit("API token not found. Please run \"dexecure-cli configure\"", exitAuth)
+
+func validationErr(msg string) error {
+	return cli.Exit(msg, exitValidation)
+}
+
+// exitErr wraps an error returned by the API client so that the CLI exits
+// with the code matching the kind of failure.
+func exitErr(err error) error {
+	return cli.Exit("Error: "+err.Error(), exitCode(err))
+}
+
+func exitCode(err error) int {
+	var netErr *client.NetworkError
+	if errors.As(err, &netErr) {
+		return exitNetwork
+	}
+
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		return exitError
+	}
+
+	switch code := apiErr.StatusCode(); {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return exitAuth
+	case code == http.StatusNotFound:
+		return exitNotFound
+	case code == http.StatusTooManyRequests:
+		return exitRateLimit
+	case code == http.StatusBadRequest || code == http.StatusUnprocessableEntity:
+		return exitValidation
+	case code == http.StatusOK || apiErr.Parameter != "":
+		// errors reported inside a successful response are validation
+		// errors raised by the API
+		return exitValidation
+	}
+	return exitError
+}
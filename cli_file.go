@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// readDocument reads a JSON or YAML file into v. YAML is converted to JSON
+// first so that it uses the same keys as the json struct tags of v.
+func readDocument(path string, v interface{}) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var doc interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	b, err = json.Marshal(jsonCompatible(doc))
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return nil
+}
+
+// jsonCompatible turns the map[interface{}]interface{} produced by yaml.v2
+// into map[string]interface{}, which encoding/json can marshal.
+func jsonCompatible(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = jsonCompatible(value)
+		}
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = jsonCompatible(v[i])
+		}
+	}
+	return v
+}
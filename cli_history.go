@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dexecure/dexecure-cli/client"
+	"github.com/urfave/cli/v2"
+)
+
+// historyDir is the directory in the config directory snapshots are saved
+// to.
+const historyDir = "history"
+
+// historyEntry is a snapshot of a website or domain, saved before a command
+// changed or deleted it. Website snapshots include all domains of the
+// website.
+type historyEntry struct {
+	Name     string                `json:"name"`
+	Time     time.Time             `json:"time"`
+	Command  string                `json:"command"`
+	Resource string                `json:"resource"`
+	ID       string                `json:"id"`
+	Website  *client.Website       `json:"website,omitempty"`
+	Domains  []client.Distribution `json:"domains"`
+}
+
+// historyRow is how snapshots are listed by "history ls".
+type historyRow struct {
+	Name     string    `json:"name"`
+	Time     time.Time `json:"time"`
+	Command  string    `json:"command"`
+	Resource string    `json:"resource"`
+	ID       string    `json:"id"`
+	Key      string    `json:"key"`
+}
+
+// commandName returns the full name of the command being run without the
+// name of the binary, e.g. "domain rules add". urfave/cli runs subcommands
+// in an app named after the parent commands.
+func commandName(c *cli.Context) string {
+	var root string
+	for _, ctx := range c.Lineage() {
+		if ctx.App != nil {
+			root = ctx.App.Name
+		}
+	}
+	return strings.TrimSpace(strings.TrimPrefix(c.App.Name, root) + " " + c.Command.Name)
+}
+
+// saveHistory saves e as a new snapshot. Commands call it before they
+// change anything and give up if it fails.
+func saveHistory(c *cli.Context, e historyEntry) error {
+	e.Time = time.Now().UTC()
+	e.Command = commandName(c)
+	e.Name = fmt.Sprintf("%s-%s-%.8s", e.Time.Format("20060102-150405.000"), e.Resource, e.ID)
+	if e.Domains == nil {
+		e.Domains = []client.Distribution{}
+	}
+	if err := savePrivate(filepath.Join(historyDir, e.Name+".json"), &e); err != nil {
+		return cli.Exit(fmt.Sprintf("Error: failed to save a snapshot, nothing was changed: %v", err), exitError)
+	}
+	return nil
+}
+
+func snapshotDistribution(c *cli.Context, d *client.Distribution) error {
+	return saveHistory(c, historyEntry{Resource: resourceDomain, ID: d.ID, Domains: []client.Distribution{*d}})
+}
+
+// snapshotDistributionID fetches the domain with the given ID and saves a
+// snapshot of it.
+func snapshotDistributionID(c *cli.Context, cl *client.Client, id string) error {
+	d, err := cl.GetDistribution(id)
+	if err != nil {
+		return exitErr(err)
+	}
+	return snapshotDistribution(c, d)
+}
+
+// snapshotWebsiteID fetches the website with the given ID and its domains
+// and saves a snapshot of them.
+func snapshotWebsiteID(c *cli.Context, cl *client.Client, id string) error {
+	w, err := cl.GetWebsite(id)
+	if err != nil {
+		return exitErr(err)
+	}
+	dists, err := cl.ListWebsiteDistributions(id)
+	if err != nil {
+		return exitErr(err)
+	}
+	return saveHistory(c, historyEntry{Resource: resourceWebsite, ID: id, Website: w, Domains: dists})
+}
+
+// snapshotStep saves the resource changed or deleted by s.
+func snapshotStep(c *cli.Context, cl *client.Client, s step) error {
+	switch {
+	case s.Op == opCreate:
+		return nil
+	case s.Resource == resourceWebsite:
+		return snapshotWebsiteID(c, cl, s.ID)
+	default:
+		return snapshotDistributionID(c, cl, s.ID)
+	}
+}
+
+func readHistory(name string) (*historyEntry, error) {
+	name = strings.TrimSuffix(filepath.Base(name), ".json")
+	path := filepath.Join(configDir(), historyDir, name+".json")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, cli.Exit(fmt.Sprintf("Snapshot %s not found. Run \"dexecure-cli history ls\" to list the snapshots", name), exitNotFound)
+	}
+
+	var e historyEntry
+	if err := readDocument(path, &e); err != nil {
+		return nil, validationErr(err.Error())
+	}
+	return &e, nil
+}
+
+// listHistory returns all snapshots, the newest first.
+func listHistory() ([]historyRow, error) {
+	files, err := ioutil.ReadDir(filepath.Join(configDir(), historyDir))
+	if os.IsNotExist(err) {
+		return []historyRow{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []historyRow{}
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		e, err := readHistory(f.Name())
+		if err != nil {
+			continue
+		}
+		row := historyRow{Name: e.Name, Time: e.Time, Command: e.Command, Resource: e.Resource, ID: e.ID}
+		if e.Website != nil {
+			row.Key = e.Website.WebsiteURL
+		} else if len(e.Domains) > 0 {
+			row.Key = e.Domains[0].Origin
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Name > rows[j].Name })
+	return rows, nil
+}
+
+// rollbackDomain returns the step that brings the domain back to its
+// settings in want. Domains that were deleted are created again in the
+// website with the given URL.
+func rollbackDomain(cl *client.Client, want client.Distribution, websiteURL string) (*step, error) {
+	normalizeDistribution(&want)
+	live, err := cl.GetDistribution(want.ID)
+	if exitCode(err) == exitNotFound {
+		clearServerFields(&want)
+		return &step{Op: opCreate, Resource: resourceDomain, Key: want.Origin, Website: websiteURL,
+			Domain: &want, Changes: createChanges(&want)}, nil
+	}
+	if err != nil {
+		return nil, exitErr(err)
+	}
+
+	normalizeDistribution(live)
+	copyServerFields(&want, live)
+	changes := diffFields(live, &want)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return &step{Op: opUpdate, Resource: resourceDomain, Key: want.Origin, ID: live.ID, Website: websiteURL,
+		Domain: &want, Changes: changes}, nil
+}
+
+// rollbackSteps returns the steps that restore the snapshot e.
+func rollbackSteps(cl *client.Client, e *historyEntry) ([]step, error) {
+	var steps []step
+	var websiteURL string
+
+	if e.Website != nil {
+		websiteURL = e.Website.WebsiteURL
+		_, err := cl.GetWebsite(e.Website.ID)
+		if exitCode(err) == exitNotFound {
+			wr := client.WebsiteRequest{WebsiteURL: e.Website.WebsiteURL, WebsiteType: e.Website.WebsiteType, WebsiteName: e.Website.WebsiteName}
+			steps = append(steps, step{Op: opCreate, Resource: resourceWebsite, Key: wr.WebsiteURL, WebsiteRequest: &wr,
+				Changes: diffFields(&client.WebsiteRequest{}, &wr)})
+		} else if err != nil {
+			return nil, exitErr(err)
+		}
+	}
+
+	for _, d := range e.Domains {
+		url := websiteURL
+		if url == "" {
+			w, err := cl.GetWebsite(d.WebsiteID)
+			if exitCode(err) == exitNotFound {
+				return nil, cli.Exit(fmt.Sprintf("The website of %s domain was deleted. Roll back a snapshot of the website instead", d.Origin), exitNotFound)
+			}
+			if err != nil {
+				return nil, exitErr(err)
+			}
+			url = w.WebsiteURL
+		}
+
+		s, err := rollbackDomain(cl, d, url)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			steps = append(steps, *s)
+		}
+	}
+	return steps, nil
+}
+
+var historyCommand = &cli.Command{
+	Name:  "history",
+	Usage: "snapshots of websites and domains saved before they were changed",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "ls",
+			Usage: "List the saved snapshots, newest first",
+			Action: func(c *cli.Context) error {
+				rows, err := listHistory()
+				if err != nil {
+					return cli.Exit("Error: "+err.Error(), exitError)
+				}
+				return render(c, rows, func() {
+					if len(rows) == 0 {
+						fmt.Println("No snapshots saved yet.")
+					}
+					for _, r := range rows {
+						fmt.Printf("%s  %s %s (%s) before \"%s\"\n", r.Name, r.Resource, r.Key, r.ID, r.Command)
+					}
+				})
+			},
+		},
+	},
+}
+
+var rollbackCommand = &cli.Command{
+	Name:      "rollback",
+	Usage:     "Restore a website or domain from a snapshot, creating it again if it was deleted",
+	ArgsUsage: "<snapshot>",
+	Action: func(c *cli.Context) error {
+		if getToken(c) == "" {
+			return errNoToken
+		}
+		if !c.Args().Present() {
+			return validationErr("Please enter the name of a snapshot, as shown by \"dexecure-cli history ls\"")
+		}
+
+		e, err := readHistory(c.Args().First())
+		if err != nil {
+			return err
+		}
+		steps, err := rollbackSteps(newClient(c), e)
+		if err != nil {
+			return err
+		}
+		return applySteps(c, steps)
+	},
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Dexecure/dexecure-cli/client"
+	"github.com/urfave/cli/v2"
+)
+
+// planChecker checks changes against the plan of the account before they
+// are sent to the API. The user is fetched the first time it's needed.
+//
+// Only what the user data of the API describes is checked: the number of
+// domains the plan allows and the features enabled for the account, private
+// S3 origins and third-party optimization (TPO), which is the proxy setting.
+type planChecker struct {
+	cl   *client.Client
+	user *client.User
+}
+
+func newPlanChecker(c *cli.Context) *planChecker {
+	return &planChecker{cl: newClient(c)}
+}
+
+func (p *planChecker) getUser() (*client.User, error) {
+	if p.user == nil {
+		user, err := p.cl.GetUser()
+		if err != nil {
+			return nil, exitErr(err)
+		}
+		p.user = user
+	}
+	return p.user, nil
+}
+
+// checkFeatures checks the features turned on by changing before into
+// after. Use an empty before for new domains.
+func (p *planChecker) checkFeatures(before, after *client.Distribution) error {
+	s3 := after.S3BucketIsOrigin && !before.S3BucketIsOrigin
+	proxy := turnsOnProxy(before, after)
+	if !s3 && !proxy {
+		return nil
+	}
+
+	u, err := p.getUser()
+	if err != nil {
+		return err
+	}
+	origin := after.Origin
+	if origin == "" {
+		origin = before.Origin
+	}
+	if s3 && u.FeaturePrivateS3 != 1 {
+		return cli.Exit(fmt.Sprintf("Can't serve %s domain from a private S3 bucket: private S3 origins aren't enabled for your account (plan %s, tier %d). Please contact Dexecure support to enable them",
+			origin, u.Plan.Name, u.Plan.Tier), exitPlan)
+	}
+	if proxy && u.FeatureTPO != 1 {
+		return cli.Exit(fmt.Sprintf("Can't turn on proxy for %s domain: third-party optimization (TPO) isn't enabled for your account (plan %s, tier %d). Please contact Dexecure support to enable it",
+			origin, u.Plan.Name, u.Plan.Tier), exitPlan)
+	}
+	return nil
+}
+
+// turnsOnProxy reports whether after proxies requests where before didn't,
+// through the proxy setting or a new rule with the proxy action.
+func turnsOnProxy(before, after *client.Distribution) bool {
+	if after.ProxyEnabled && !before.ProxyEnabled {
+		return true
+	}
+	for _, r := range after.Rules {
+		if !hasAction(r, "proxy") {
+			continue
+		}
+		found := false
+		for _, old := range before.Rules {
+			found = found || sameRule(old, r)
+		}
+		if !found {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAction(r client.Rule, action string) bool {
+	for _, a := range r.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDomainLimit fails if adding n domains exceeds the number of domains
+// the plan allows.
+func (p *planChecker) checkDomainLimit(n int) error {
+	if n <= 0 {
+		return nil
+	}
+	u, err := p.getUser()
+	if err != nil {
+		return err
+	}
+	if u.Plan.MaxDistributions <= 0 {
+		return nil
+	}
+
+	usage, err := p.cl.GetUsage()
+	if err != nil {
+		return exitErr(err)
+	}
+	if usage.Distributions+n > u.Plan.MaxDistributions {
+		return cli.Exit(fmt.Sprintf("Can't add %d domains: your plan %s is tier %d and allows %d domains, you have %d. Please upgrade to a higher tier plan or delete a domain first",
+			n, u.Plan.Name, u.Plan.Tier, u.Plan.MaxDistributions, usage.Distributions), exitPlan)
+	}
+	return nil
+}
+
+// checkSteps checks all changes of steps against the plan. Domains deleted
+// by the steps make room for the ones created.
+func (p *planChecker) checkSteps(steps []step) error {
+	added := 0
+	for _, s := range steps {
+		if s.Resource != resourceDomain {
+			continue
+		}
+
+		before := &client.Distribution{}
+		switch s.Op {
+		case opDelete:
+			added--
+			continue
+		case opCreate:
+			added++
+		default:
+			if !s.Domain.S3BucketIsOrigin && !turnsOnProxy(&client.Distribution{}, s.Domain) {
+				continue
+			}
+			live, err := p.cl.GetDistribution(s.ID)
+			if err != nil {
+				return exitErr(err)
+			}
+			before = live
+		}
+		if err := p.checkFeatures(before, s.Domain); err != nil {
+			return err
+		}
+	}
+	return p.checkDomainLimit(added)
+}
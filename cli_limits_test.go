@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Dexecure/dexecure-cli/client"
+)
+
+func TestTurnsOnProxy(t *testing.T) {
+	proxyRule := client.Rule{Pattern: "/cdn/*", Actions: []string{"proxy"}}
+	tests := []struct {
+		name          string
+		before, after client.Distribution
+		want          bool
+	}{
+		{"nothing", client.Distribution{}, client.Distribution{}, false},
+		{"setting turned on", client.Distribution{}, client.Distribution{ProxyEnabled: true}, true},
+		{"setting already on", client.Distribution{ProxyEnabled: true}, client.Distribution{ProxyEnabled: true}, false},
+		{"new rule", client.Distribution{}, client.Distribution{Rules: []client.Rule{proxyRule}}, true},
+		{"existing rule", client.Distribution{Rules: []client.Rule{proxyRule}}, client.Distribution{Rules: []client.Rule{proxyRule}}, false},
+		{"rule turning it off", client.Distribution{}, client.Distribution{Rules: []client.Rule{{Pattern: "/cdn/*", Actions: []string{"no-proxy"}}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := turnsOnProxy(&tt.before, &tt.after); got != tt.want {
+				t.Errorf("turnsOnProxy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
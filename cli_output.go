@@ -0,0 +1,559 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/Dexecure/dexecure-cli/client"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+)
+
+var outputFormats = []string{"text", "json", "yaml", "table", "csv"}
+
+var outputFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:    "output",
+		Aliases: []string{"o"},
+		Value:   "text",
+		Usage:   "output format (" + strings.Join(outputFormats, "|") + ")",
+	},
+	&cli.StringFlag{
+		Name:  "format",
+		Usage: "print every result with a Go template, e.g. '{{.ID}} {{.Origin}}'",
+	},
+	&cli.StringFlag{
+		Name:  "columns",
+		Usage: "comma separated columns to show in table and csv output, e.g. id,origin,status",
+	},
+	&cli.BoolFlag{
+		Name:  "wide",
+		Usage: "show every column in table output",
+	},
+}
+
+// compactColumns are the columns shown in table output when neither
+// --columns nor --wide is given. Types not listed here show every column.
+var compactColumns = map[reflect.Type][]string{
+	reflect.TypeOf(client.Distribution{}): {"id", "origin", "name", "status", "websiteId"},
+	reflect.TypeOf(client.User{}):         {"id", "firstName", "lastName", "email", "role", "isVerified"},
+}
+
+func checkOutputFormat(c *cli.Context) error {
+	if format := c.String("format"); format != "" {
+		if _, err := parseTemplate(format); err != nil {
+			return validationErr(fmt.Sprintf("Invalid --format template: %s", err))
+		}
+		return nil
+	}
+
+	format := c.String("output")
+	for _, f := range outputFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return validationErr(fmt.Sprintf("Unknown output format %q. It must be one of %s", format, strings.Join(outputFormats, ", ")))
+}
+
+func parseTemplate(format string) (*template.Template, error) {
+	return template.New("format").Funcs(template.FuncMap{
+		"join": strings.Join,
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(format)
+}
+
+// message is the result of commands that only get a message back from the API.
+type message struct {
+	Message string `json:"message"`
+}
+
+// render writes v to stdout in the format selected with --output. text is
+// called to print the default human readable output.
+func render(c *cli.Context, v interface{}, text func()) error {
+	if format := c.String("format"); format != "" {
+		return renderErr(writeTemplate(os.Stdout, v, format))
+	}
+
+	var columns []string
+	if c.String("columns") != "" {
+		columns = strings.Split(c.String("columns"), ",")
+	}
+
+	var err error
+	switch c.String("output") {
+	case "json", "yaml":
+		var doc interface{}
+		if doc, err = withSecondsText(v); err != nil {
+			break
+		}
+		if c.String("output") == "json" {
+			err = writeJSON(os.Stdout, doc)
+		} else {
+			err = writeYAML(os.Stdout, doc)
+		}
+	case "table":
+		if columns == nil && !c.Bool("wide") {
+			columns = compactColumns[elemType(v)]
+		}
+		err = writeTable(os.Stdout, v, columns)
+	case "csv":
+		err = writeCSV(os.Stdout, v, columns)
+	default:
+		text()
+	}
+	return renderErr(err)
+}
+
+func renderErr(err error) error {
+	var colErr *columnError
+	if errors.As(err, &colErr) {
+		return validationErr(colErr.Error())
+	}
+	if err != nil {
+		return cli.Exit("Error: "+err.Error(), exitError)
+	}
+	return nil
+}
+
+func renderMessage(c *cli.Context, msg string) error {
+	return render(c, message{Message: msg}, func() {
+		fmt.Println(msg)
+	})
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// writeYAML writes v as YAML using the same keys as the JSON output. It goes
+// through JSON so that the json struct tags of the models are honoured.
+func writeYAML(w io.Writer, v interface{}) error {
+	doc, err := decodeJSON(v)
+	if err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// decodeJSON returns v as decoded by decodeOrdered from its JSON.
+func decodeJSON(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return decodeOrdered(dec)
+}
+
+// decodeOrdered decodes the next JSON value from dec, keeping objects as
+// yaml.MapSlice so that keys are written in their original order.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		ms := yaml.MapSlice{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			ms = append(ms, yaml.MapItem{Key: key, Value: value})
+		}
+		_, err = dec.Token()
+		return ms, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err = dec.Token()
+		return list, err
+	}
+
+	if n, ok := tok.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+		return n.Float64()
+	}
+	return tok, nil
+}
+
+// orderedObject is an object decoded by decodeOrdered. It's written as JSON
+// with its keys in the same order.
+type orderedObject yaml.MapSlice
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, item := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(fmt.Sprint(item.Key))
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(ordered(item.Value))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// ordered makes the objects of doc, as returned by decodeOrdered, keep the
+// order of their keys when written as JSON.
+func ordered(doc interface{}) interface{} {
+	switch doc := doc.(type) {
+	case yaml.MapSlice:
+		return orderedObject(doc)
+	case []interface{}:
+		list := make([]interface{}, len(doc))
+		for i, value := range doc {
+			list[i] = ordered(value)
+		}
+		return list
+	}
+	return doc
+}
+
+var secondsType = reflect.TypeOf(client.Seconds(0))
+
+// withSecondsText returns v as written to JSON, with a "<name>Text" field
+// holding the human readable form next to every cache time, e.g.
+// defaultCacheTimeText: 1d next to defaultCacheTime: 86400.
+func withSecondsText(v interface{}) (interface{}, error) {
+	doc, err := decodeJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	return ordered(addSecondsText(reflect.ValueOf(v), doc)), nil
+}
+
+// addSecondsText adds the text fields of the Seconds in v to doc, which
+// is v decoded by decodeOrdered.
+func addSecondsText(v reflect.Value, doc interface{}) interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return doc
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		ms, ok := doc.(yaml.MapSlice)
+		if !ok {
+			return doc
+		}
+		fields := map[string]reflect.Value{}
+		for _, f := range tableFields(v.Type(), nil) {
+			fields[jsonName(v.Type().FieldByIndex(f))] = v.FieldByIndex(f)
+		}
+		out := yaml.MapSlice{}
+		for _, item := range ms {
+			name := fmt.Sprint(item.Key)
+			fv, ok := fields[name]
+			if !ok {
+				out = append(out, item)
+				continue
+			}
+			item.Value = addSecondsText(fv, item.Value)
+			out = append(out, item)
+			if text := secondsText(fv, item.Value); text != nil {
+				out = append(out, yaml.MapItem{Key: name + "Text", Value: text})
+			}
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		list, ok := doc.([]interface{})
+		if !ok || len(list) != v.Len() {
+			return doc
+		}
+		out := make([]interface{}, len(list))
+		for i := range list {
+			out[i] = addSecondsText(v.Index(i), list[i])
+		}
+		return out
+	case reflect.Map:
+		ms, ok := doc.(yaml.MapSlice)
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return doc
+		}
+		out := make(yaml.MapSlice, len(ms))
+		for i, item := range ms {
+			key := reflect.ValueOf(fmt.Sprint(item.Key)).Convert(v.Type().Key())
+			item.Value = addSecondsText(v.MapIndex(key), item.Value)
+			out[i] = item
+		}
+		return out
+	}
+	return doc
+}
+
+// secondsText returns the human readable form of v if it's Seconds, or a
+// map of them in the order of doc. It returns nil for anything else.
+func secondsText(v reflect.Value, doc interface{}) interface{} {
+	v = reflect.Indirect(v)
+	switch {
+	case !v.IsValid():
+		return nil
+	case v.Type() == secondsType:
+		return v.Interface().(client.Seconds).String()
+	case v.Kind() == reflect.Map && v.Type().Elem() == secondsType && v.Type().Key().Kind() == reflect.String:
+		ms, ok := doc.(yaml.MapSlice)
+		if !ok {
+			return nil
+		}
+		text := yaml.MapSlice{}
+		for _, item := range ms {
+			s := v.MapIndex(reflect.ValueOf(fmt.Sprint(item.Key)).Convert(v.Type().Key()))
+			if s.IsValid() {
+				text = append(text, yaml.MapItem{Key: item.Key, Value: s.Interface().(client.Seconds).String()})
+			}
+		}
+		return text
+	}
+	return nil
+}
+
+// writeTemplate executes format for v, or for every element when v is a
+// slice, and ends each result with a newline.
+func writeTemplate(w io.Writer, v interface{}, format string) error {
+	tmpl, err := parseTemplate(format)
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	items := []interface{}{rv.Interface()}
+	if rv.Kind() == reflect.Slice {
+		items = items[:0]
+		for i := 0; i < rv.Len(); i++ {
+			items = append(items, rv.Index(i).Interface())
+		}
+	}
+
+	for _, item := range items {
+		if err := tmpl.Execute(w, item); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+func writeTable(w io.Writer, v interface{}, columns []string) error {
+	header, rows, err := tabulate(v, columns)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, v interface{}, columns []string) error {
+	header, rows, err := tabulate(v, columns)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	cw.WriteAll(rows)
+	return cw.Error()
+}
+
+// columnError is returned when a column asked for with --columns doesn't exist.
+type columnError struct {
+	column    string
+	available []string
+}
+
+func (e *columnError) Error() string {
+	return fmt.Sprintf("Unknown column %q. Available columns: %s", e.column, strings.Join(e.available, ","))
+}
+
+// elemType returns the struct type rendered for v, looking through pointers
+// and slices.
+func elemType(v interface{}) reflect.Type {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t
+}
+
+// tabulate flattens a struct or a slice of structs into a header and one
+// row per struct. Columns are named after the json tags of the fields and
+// are matched case insensitively; a nil columns shows every field. Values
+// that aren't structs, such as a list of strings, get a single value column.
+func tabulate(v interface{}, columns []string) ([]string, [][]string, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+
+	var items []reflect.Value
+	if rv.Kind() == reflect.Slice {
+		for i := 0; i < rv.Len(); i++ {
+			items = append(items, reflect.Indirect(rv.Index(i)))
+		}
+	} else {
+		items = append(items, rv)
+	}
+
+	et := elemType(v)
+	var header []string
+	var fields [][]int
+	if et.Kind() == reflect.Struct {
+		for _, f := range tableFields(et, nil) {
+			header = append(header, jsonName(et.FieldByIndex(f)))
+			fields = append(fields, f)
+		}
+	} else {
+		header, fields = []string{"value"}, [][]int{nil}
+	}
+
+	if columns != nil {
+		var selected []string
+		var selectedFields [][]int
+		for _, col := range columns {
+			col = strings.TrimSpace(col)
+			found := false
+			for i, name := range header {
+				if strings.EqualFold(col, name) {
+					selected = append(selected, name)
+					selectedFields = append(selectedFields, fields[i])
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, nil, &columnError{column: col, available: header}
+			}
+		}
+		header, fields = selected, selectedFields
+	}
+
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		row := make([]string, 0, len(fields))
+		for _, f := range fields {
+			if f == nil {
+				row = append(row, cell(item))
+				continue
+			}
+			row = append(row, cell(item.FieldByIndex(f)))
+		}
+		rows = append(rows, row)
+	}
+	return header, rows, nil
+}
+
+// tableFields returns the index of every exported field of t, flattening
+// embedded structs the same way encoding/json does.
+func tableFields(t reflect.Type, index []int) [][]int {
+	var fields [][]int
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fi := append(append([]int{}, index...), i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			fields = append(fields, tableFields(f.Type, fi)...)
+			continue
+		}
+		if jsonName(f) == "" {
+			continue
+		}
+		fields = append(fields, fi)
+	}
+	return fields
+}
+
+func jsonName(f reflect.StructField) string {
+	if f.PkgPath != "" {
+		return ""
+	}
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
+
+// cell formats a single field value for table and csv output. Values with a
+// String method use it, lists of strings are joined with commas and anything
+// nested is written as JSON.
+func cell(v reflect.Value) string {
+	if stringer, ok := v.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Slice:
+		if v.Len() == 0 && v.Type().Elem().Kind() != reflect.String {
+			return "[]"
+		}
+		if v.Type().Elem().Kind() == reflect.String {
+			values := make([]string, v.Len())
+			for i := range values {
+				values[i] = v.Index(i).String()
+			}
+			return strings.Join(values, ",")
+		}
+	}
+	b, _ := json.Marshal(v.Interface())
+	return string(b)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Dexecure/dexecure-cli/client"
+)
+
+func TestTabulate(t *testing.T) {
+	type row struct {
+		ID     string   `json:"id"`
+		Names  []string `json:"names"`
+		hidden string
+	}
+
+	tests := []struct {
+		name    string
+		v       interface{}
+		columns []string
+		header  []string
+		rows    [][]string
+	}{
+		{"struct", row{ID: "a", Names: []string{"x", "y"}}, nil, []string{"id", "names"}, [][]string{{"a", "x,y"}}},
+		{"slice of structs", []row{{ID: "a"}, {ID: "b"}}, []string{"ID"}, []string{"id"}, [][]string{{"a"}, {"b"}}},
+		{"slice of strings", []string{"a", "b"}, nil, []string{"value"}, [][]string{{"a"}, {"b"}}},
+		{"string", "a", []string{"value"}, []string{"value"}, [][]string{{"a"}}},
+		{"empty slice of strings", []string{}, nil, []string{"value"}, [][]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, rows, err := tabulate(tt.v, tt.columns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(header, tt.header) {
+				t.Errorf("header = %q, want %q", header, tt.header)
+			}
+			if !reflect.DeepEqual(rows, tt.rows) {
+				t.Errorf("rows = %q, want %q", rows, tt.rows)
+			}
+		})
+	}
+}
+
+func TestTabulateUnknownColumn(t *testing.T) {
+	if _, _, err := tabulate([]string{"a"}, []string{"id"}); err == nil {
+		t.Error("expected an error for an unknown column")
+	}
+}
+
+func TestWithSecondsText(t *testing.T) {
+	d := client.Distribution{Origin: "example.com", DefaultCacheTime: client.Day}
+	d.ErrorCaching.ServerError = map[string]client.Seconds{"500": 90}
+	doc, err := withSecondsText([]client.Distribution{d})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`"defaultCacheTime":86400,"defaultCacheTimeText":"1d","rules"`,
+		`"serverError":{"500":90},"serverErrorText":{"500":"1m30s"}`,
+		`"clientError":{"default":0,"defaultText":"0s"}`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("%s doesn't contain %s", b, want)
+		}
+	}
+}
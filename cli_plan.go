@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/Dexecure/dexecure-cli/client"
+	"github.com/urfave/cli/v2"
+)
+
+const planVersion = 1
+
+// planFile is a plan saved by "plan --out". Fingerprint identifies the
+// state of the account the plan was made for, so that a plan is never run
+// against an account that changed in the meantime.
+type planFile struct {
+	Version     int    `json:"version"`
+	Fingerprint string `json:"fingerprint"`
+	Steps       []step `json:"steps"`
+}
+
+// fingerprint returns a hash of the websites and domains of live. The status
+// of domains is left out as it changes while they are deployed.
+func fingerprint(live *account) string {
+	websites := append([]client.Website{}, live.Websites...)
+	sort.Slice(websites, func(i, j int) bool { return websites[i].ID < websites[j].ID })
+
+	var dists []client.Distribution
+	for _, d := range live.Distributions {
+		d = copyDistribution(&d)
+		d.Status = ""
+		normalizeDistribution(&d)
+		dists = append(dists, d)
+	}
+	sort.Slice(dists, func(i, j int) bool { return dists[i].ID < dists[j].ID })
+
+	b, _ := json.Marshal(account{Websites: websites, Distributions: dists})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func writePlan(path string, live *account, steps []step) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if steps == nil {
+		steps = []step{}
+	}
+	return writeJSON(f, planFile{Version: planVersion, Fingerprint: fingerprint(live), Steps: steps})
+}
+
+// readPlan reads a plan saved by "plan --out" and checks that it was made
+// for the current state of the account.
+func readPlan(c *cli.Context, path string) ([]step, error) {
+	var plan planFile
+	if err := readDocument(path, &plan); err != nil {
+		return nil, validationErr(err.Error())
+	}
+	if plan.Version != planVersion || plan.Fingerprint == "" {
+		return nil, validationErr(fmt.Sprintf("%s is not a plan written by \"dexecure-cli plan --out\"", path))
+	}
+
+	live, err := fetchAccount(newClient(c))
+	if err != nil {
+		return nil, err
+	}
+	if fingerprint(live) != plan.Fingerprint {
+		return nil, validationErr("The account changed since the plan was made. Please run plan again")
+	}
+	return plan.Steps, nil
+}
+
+// planSummary counts the steps by operation.
+func planSummary(steps []step) string {
+	count := map[string]int{}
+	for _, s := range steps {
+		count[s.Op]++
+	}
+	return fmt.Sprintf("Plan: %d to add, %d to change, %d to destroy.", count[opCreate], count[opUpdate], count[opDelete])
+}
+
+var planCommand = &cli.Command{
+	Name:  "plan",
+	Usage: "Show the changes apply would make for a file written by export",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "file", Aliases: []string{"f"}, Usage: "YAML or JSON file describing the websites and domains", Required: true},
+		&cli.BoolFlag{Name: "prune", Usage: "also delete websites and domains missing from the file"},
+		&cli.StringFlag{Name: "out", Usage: "save the plan to this file, to be run with \"apply <file>\""},
+	},
+	Action: func(c *cli.Context) error {
+		if getToken(c) == "" {
+			return errNoToken
+		}
+
+		desired, err := readState(c.String("file"))
+		if err != nil {
+			return err
+		}
+		live, err := fetchAccount(newClient(c))
+		if err != nil {
+			return err
+		}
+		steps, err := planSteps(live, desired, c.Bool("prune"))
+		if err != nil {
+			return err
+		}
+
+		if c.String("out") != "" {
+			if err := writePlan(c.String("out"), live, steps); err != nil {
+				return cli.Exit("Error: "+err.Error(), exitError)
+			}
+		}
+
+		if steps == nil {
+			steps = []step{}
+		}
+		err = render(c, steps, func() {
+			if len(steps) == 0 {
+				fmt.Println("No changes. The account matches the file.")
+				return
+			}
+			printSteps(steps)
+			fmt.Println()
+			fmt.Println(planSummary(steps))
+			if c.String("out") != "" {
+				fmt.Printf("Saved the plan to %s. Run \"dexecure-cli apply %s\" to make these changes.\n", c.String("out"), c.String("out"))
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if len(steps) > 0 {
+			return cli.Exit("", exitChanges)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Dexecure/dexecure-cli/client"
+	"github.com/urfave/cli/v2"
+)
+
+// builtInPresets are the presets shipped with the CLI. They can't be
+// overwritten by saved presets.
+var builtInPresets = map[string]*Preset{
+	"images-only": {Settings: map[string]bool{
+		"image": true, "svg": true, "gif": true, "auto-rotate": true,
+		"js": false, "css": false, "font": false, "proxy": false,
+	}},
+	"full": {Settings: map[string]bool{
+		"js": true, "css": true, "image": true, "svg": true, "font": true, "gif": true,
+		"heif": true, "zopflipng": true, "auto-resize": true, "auto-rotate": true,
+		"proxy": false,
+	}},
+	"proxy-only": {Settings: map[string]bool{
+		"proxy": true, "js": false, "css": false, "image": false, "svg": false, "font": false, "gif": false,
+		"heif": false, "zopflipng": false, "auto-resize": false, "auto-rotate": false,
+		"text-detection": false, "face-detection": false,
+	}},
+}
+
+var presetNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+var presetFlag = &cli.StringFlag{Name: "preset", Usage: "apply the settings of a preset, see \"dexecure-cli preset ls\""}
+
+// lookupPreset returns the built-in or saved preset with the given name.
+func lookupPreset(name string) (*Preset, error) {
+	if p, ok := builtInPresets[name]; ok {
+		return p, nil
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return nil, exitErr(err)
+	}
+	if p, ok := config.Presets[name]; ok {
+		return p, validatePreset(name, p)
+	}
+	return nil, cli.Exit(fmt.Sprintf("Preset %q not found. Run \"dexecure-cli preset ls\" to list the presets", name), exitNotFound)
+}
+
+// presetOf returns a preset with all optimization settings of d.
+func presetOf(d *client.Distribution) *Preset {
+	p := &Preset{Settings: map[string]bool{}}
+	for _, t := range distributionToggles {
+		p.Settings[t.flag] = *t.field(d)
+	}
+	cacheTime := d.DefaultCacheTime
+	p.DefaultCacheTime = &cacheTime
+	return p
+}
+
+// applyPreset changes the settings of d to the ones of p.
+func applyPreset(p *Preset, d *client.Distribution) {
+	for _, t := range distributionToggles {
+		if on, ok := p.Settings[t.flag]; ok {
+			*t.field(d) = on
+		}
+	}
+	if p.DefaultCacheTime != nil {
+		d.DefaultCacheTime = *p.DefaultCacheTime
+	}
+}
+
+// presetSettings lists the settings of p in the order of the flags of
+// "domain set".
+func presetSettings(p *Preset) []setting {
+	var settings []setting
+	if p.DefaultCacheTime != nil {
+		settings = append(settings, setting{Name: "default-cache-time", Value: p.DefaultCacheTime.String()})
+	}
+	for _, t := range distributionToggles {
+		if on, ok := p.Settings[t.flag]; ok {
+			settings = append(settings, setting{Name: t.flag, Value: strconv.FormatBool(on)})
+		}
+	}
+	return settings
+}
+
+func summarizePreset(p *Preset) string {
+	var on []string
+	for _, st := range presetSettings(p) {
+		switch st.Value {
+		case "true":
+			on = append(on, st.Name)
+		case "false":
+		default:
+			on = append(on, st.Name+"="+st.Value)
+		}
+	}
+	if len(on) == 0 {
+		return "everything off"
+	}
+	return strings.Join(on, ",")
+}
+
+// validatePreset checks that all settings of p are known, as presets can
+// be edited by hand in config.json.
+func validatePreset(name string, p *Preset) error {
+	for flag := range p.Settings {
+		found := false
+		for _, t := range distributionToggles {
+			found = found || t.flag == flag
+		}
+		if !found {
+			return validationErr(fmt.Sprintf("Preset %s has an unknown setting %q", name, flag))
+		}
+	}
+	return nil
+}
+
+var presetCommand = &cli.Command{
+	Name:  "preset",
+	Usage: "manage named sets of optimization settings",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "ls",
+			Usage: "List the built-in and saved presets",
+			Action: func(c *cli.Context) error {
+				config, err := loadConfig()
+				if err != nil {
+					return exitErr(err)
+				}
+
+				presets := []presetInfo{}
+				for name, p := range builtInPresets {
+					presets = append(presets, presetInfo{Name: name, BuiltIn: true, Settings: summarizePreset(p)})
+				}
+				for name, p := range config.Presets {
+					presets = append(presets, presetInfo{Name: name, Settings: summarizePreset(p)})
+				}
+				sort.Slice(presets, func(i, j int) bool {
+					return presets[i].Name < presets[j].Name
+				})
+
+				return render(c, presets, func() {
+					for _, p := range presets {
+						kind := "saved"
+						if p.BuiltIn {
+							kind = "built-in"
+						}
+						fmt.Printf("%s (%s): %s\n", p.Name, kind, p.Settings)
+					}
+				})
+			},
+		},
+		{
+			Name:      "show",
+			Usage:     "Show the settings of a preset",
+			ArgsUsage: "<preset>",
+			Action: func(c *cli.Context) error {
+				if !c.Args().Present() {
+					return validationErr("Please enter the name of the preset")
+				}
+				p, err := lookupPreset(c.Args().First())
+				if err != nil {
+					return err
+				}
+
+				settings := presetSettings(p)
+				return render(c, settings, func() {
+					for _, st := range settings {
+						fmt.Printf("%s: %s\n", st.Name, st.Value)
+					}
+				})
+			},
+		},
+		{
+			Name:      "save",
+			Usage:     "Save the optimization settings of a domain as a preset",
+			ArgsUsage: "<preset>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "from", Usage: "ID of the domain to take the settings from", Required: true},
+			},
+			Action: func(c *cli.Context) error {
+				name := c.Args().First()
+				switch {
+				case !presetNameRegexp.MatchString(name):
+					return validationErr("Please enter a preset name made of lowercase letters, digits, dots, dashes and underscores")
+				case builtInPresets[name] != nil:
+					return validationErr(fmt.Sprintf("%s is a built-in preset and can't be overwritten", name))
+				}
+
+				id := c.String("from")
+				if isValidUUID(id) == false {
+					return validationErr("Please enter a valid domain ID. It must be a valid UUID")
+				}
+				if getToken(c) == "" {
+					return errNoToken
+				}
+				d, err := newClient(c).GetDistribution(id)
+				if err != nil {
+					return exitErr(err)
+				}
+
+				config, err := loadConfig()
+				if err != nil {
+					return exitErr(err)
+				}
+				if config.Presets == nil {
+					config.Presets = map[string]*Preset{}
+				}
+				config.Presets[name] = presetOf(d)
+				if err := saveConfig(config); err != nil {
+					return exitErr(err)
+				}
+				fmt.Printf("Saved the settings of %s domain as preset %s.\n", d.Origin, name)
+				return nil
+			},
+		},
+		{
+			Name:      "rm",
+			Usage:     "Delete a saved preset",
+			ArgsUsage: "<preset>",
+			Action: func(c *cli.Context) error {
+				name := c.Args().First()
+				if builtInPresets[name] != nil {
+					return validationErr(fmt.Sprintf("%s is a built-in preset and can't be deleted", name))
+				}
+
+				config, err := loadConfig()
+				if err != nil {
+					return exitErr(err)
+				}
+				if _, ok := config.Presets[name]; !ok {
+					return cli.Exit(fmt.Sprintf("Preset %q not found", name), exitNotFound)
+				}
+				delete(config.Presets, name)
+				if err := saveConfig(config); err != nil {
+					return exitErr(err)
+				}
+				fmt.Printf("Deleted preset %s.\n", name)
+				return nil
+			},
+		},
+	},
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+// stdin is shared by every prompt so that input buffered while reading one
+// answer isn't lost to the next prompt.
+var stdin = bufio.NewReader(os.Stdin)
+
+// isTerminal reports whether stdin is an interactive terminal.
+func isTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// prompt prints label and returns the next line read from stdin.
+func prompt(label string) string {
+	fmt.Print(label)
+	line, _ := stdin.ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// promptSecret is like prompt but doesn't echo what is typed.
+func promptSecret(label string) (string, error) {
+	fmt.Print(label)
+	secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	return strings.TrimSpace(string(secret)), err
+}
+
+// flagOrPrompt returns the value of the flag name. When the flag wasn't given
+// the user is asked for it, unless stdin isn't a terminal in which case the
+// flag is required.
+func flagOrPrompt(c *cli.Context, name, label string) (string, error) {
+	if value := strings.TrimSpace(c.String(name)); value != "" {
+		return value, nil
+	}
+	if !isTerminal() {
+		return "", validationErr(fmt.Sprintf("Missing --%s. It is required when stdin is not a terminal", name))
+	}
+	return prompt(label), nil
+}
+
+// argOrPrompt is like flagOrPrompt for commands taking an ID as their first
+// argument.
+func argOrPrompt(c *cli.Context, label string) (string, error) {
+	if c.Args().Len() > 0 {
+		return strings.TrimSpace(c.Args().First()), nil
+	}
+	if !isTerminal() {
+		return "", validationErr("Missing ID argument. It is required when stdin is not a terminal")
+	}
+	return prompt(label), nil
+}
+
+var yesFlag = &cli.BoolFlag{
+	Name:    "yes",
+	Aliases: []string{"y", "force"},
+	Usage:   "answer yes to every confirmation",
+}
+
+// confirm asks the user whether to go ahead with action. An empty answer
+// picks def, which is shown capitalised in the prompt. With --yes nothing is
+// asked, and without a terminal to ask on the action is refused.
+func confirm(c *cli.Context, action string, def bool) (bool, error) {
+	if c.Bool("yes") {
+		return true, nil
+	}
+	if !isTerminal() {
+		return false, cli.Exit("Refusing to continue without confirmation because stdin is not a terminal. Use --yes to confirm", exitAborted)
+	}
+
+	choices := "[y/N]"
+	if def {
+		choices = "[Y/n]"
+	}
+
+	switch strings.ToLower(prompt(fmt.Sprintf("%s. Are you sure? %s: ", action, choices))) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Dexecure/dexecure-cli/client"
+	"github.com/urfave/cli/v2"
+)
+
+// ruleActions are the actions documented for rules: every optimization
+// toggle to turn it on for the matching paths, "no-" followed by one to turn
+// it off, and whether the paths are cached at all. The API doesn't list the
+// actions it accepts, so knownRuleActions adds the ones it already accepted.
+func ruleActions() []string {
+	actions := []string{"cache", "no-cache"}
+	for _, t := range distributionToggles {
+		actions = append(actions, t.flag, "no-"+t.flag)
+	}
+	return actions
+}
+
+// knownRuleActions returns the documented actions together with every
+// action used by the live rules, which the API accepted when they were
+// saved.
+func knownRuleActions(live ...[]client.Rule) []string {
+	known := ruleActions()
+	seen := map[string]bool{}
+	for _, a := range known {
+		seen[a] = true
+	}
+	for _, rules := range live {
+		for _, r := range rules {
+			for _, a := range r.Actions {
+				if !seen[a] {
+					seen[a] = true
+					known = append(known, a)
+				}
+			}
+		}
+	}
+	return known
+}
+
+// validateRule checks that the pattern of r is a path, optionally with *
+// wildcards, and that r has actions.
+func validateRule(r client.Rule) error {
+	switch {
+	case r.Pattern == "":
+		return fmt.Errorf("the pattern must not be empty")
+	case !strings.HasPrefix(r.Pattern, "/") && !strings.HasPrefix(r.Pattern, "*"):
+		return fmt.Errorf("pattern %q must start with / or *", r.Pattern)
+	case strings.ContainsAny(r.Pattern, " \t\n?#"):
+		return fmt.Errorf("pattern %q must not contain whitespace, ? or #", r.Pattern)
+	case len(r.Actions) == 0:
+		return fmt.Errorf("rule %q needs at least one action", r.Pattern)
+	}
+	return nil
+}
+
+// unknownActions returns a warning for every action of r that isn't known.
+// The API may still accept them, since it doesn't list its actions.
+func unknownActions(r client.Rule, known []string) []string {
+	var warnings []string
+	for _, action := range r.Actions {
+		found := false
+		for _, k := range known {
+			if action == k {
+				found = true
+				break
+			}
+		}
+		if !found {
+			warnings = append(warnings, fmt.Sprintf("unknown action %q in rule %q", action, r.Pattern))
+		}
+	}
+	return warnings
+}
+
+// sameRule reports whether a and b have the same pattern and actions.
+func sameRule(a, b client.Rule) bool {
+	if a.Pattern != b.Pattern || len(a.Actions) != len(b.Actions) {
+		return false
+	}
+	for i := range a.Actions {
+		if a.Actions[i] != b.Actions[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// validateRules checks the rules of after that aren't in before, i.e. the
+// ones a command adds or changes, and returns warnings for their unknown
+// actions. Rules already on the server are left alone, whatever their
+// actions.
+func validateRules(known []string, before, after []client.Rule) ([]string, error) {
+	var warnings []string
+	for _, r := range after {
+		existing := false
+		for _, b := range before {
+			existing = existing || sameRule(r, b)
+		}
+		if existing {
+			continue
+		}
+		if err := validateRule(r); err != nil {
+			return nil, validationErr("Invalid rule: " + err.Error())
+		}
+		warnings = append(warnings, unknownActions(r, known)...)
+	}
+	return warnings, nil
+}
+
+// checkWarnings fails on warnings about unknown rule actions unless --force
+// is given, in which case they are printed to stderr.
+func checkWarnings(c *cli.Context, warnings []string) error {
+	if len(warnings) == 0 {
+		return nil
+	}
+	if !c.Bool("force") {
+		return validationErr(fmt.Sprintf("Invalid rule: %s. Known actions: %s. If the API accepts them, run again with --force",
+			strings.Join(warnings, ", "), strings.Join(ruleActions(), ", ")))
+	}
+	for _, w := range warnings {
+		fmt.Fprintln(os.Stderr, "Warning: "+w)
+	}
+	return nil
+}
+
+// forceFlag lets rules with unknown actions through.
+var forceFlag = &cli.BoolFlag{Name: "force", Usage: "send rules with actions unknown to the CLI anyway"}
+
+// splitActions splits a comma separated list of actions.
+func splitActions(actions string) []string {
+	var out []string
+	for _, a := range strings.Split(actions, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// readRules reads a list of rules from a JSON or YAML file.
+func readRules(path string) ([]client.Rule, error) {
+	var rules []client.Rule
+	err := readDocument(path, &rules)
+	return rules, err
+}
+
+// readTestDomain reads the domain "rules test" works on from a file: either
+// a single domain, as printed by "-o json domain ls id", or a file written
+// by export, from which origin selects the domain.
+func readTestDomain(path, origin string) (*client.Distribution, error) {
+	var doc struct {
+		client.Distribution
+		Websites []websiteState `json:"websites"`
+	}
+	if err := readDocument(path, &doc); err != nil {
+		return nil, validationErr(err.Error())
+	}
+
+	if doc.Websites == nil {
+		switch {
+		case doc.Origin == "":
+			return nil, validationErr(fmt.Sprintf("%s is neither a domain nor a file written by export: it has no origin", path))
+		case origin != "" && domainKey(origin) != domainKey(doc.Origin):
+			return nil, cli.Exit(fmt.Sprintf("%s has the settings of %s domain, not %s", path, doc.Origin, origin), exitNotFound)
+		}
+		return &doc.Distribution, nil
+	}
+
+	var domains []client.Distribution
+	var origins []string
+	for _, ws := range doc.Websites {
+		for _, d := range ws.Domains {
+			domains = append(domains, d)
+			origins = append(origins, d.Origin)
+		}
+	}
+	if origin == "" {
+		if len(domains) == 1 {
+			return &domains[0], nil
+		}
+		return nil, validationErr(fmt.Sprintf("%s has %d domains. Please pick one with --origin (%s)", path, len(domains), strings.Join(origins, ", ")))
+	}
+	for i, d := range domains {
+		if domainKey(d.Origin) == domainKey(origin) {
+			return &domains[i], nil
+		}
+	}
+	return nil, cli.Exit(fmt.Sprintf("Domain %s not found in %s", origin, path), exitNotFound)
+}
+
+// ruleRow is how rules are listed by "domain rules ls".
+type ruleRow struct {
+	Index   int      `json:"index"`
+	Pattern string   `json:"pattern"`
+	Actions []string `json:"actions"`
+}
+
+// distributionArg returns the distribution whose ID is the first argument.
+func distributionArg(c *cli.Context) (*client.Distribution, error) {
+	if getToken(c) == "" {
+		return nil, errNoToken
+	}
+
+	id, err := argOrPrompt(c, "Domain ID: ")
+	if err != nil {
+		return nil, err
+	}
+	if isValidUUID(id) == false {
+		return nil, validationErr("Please enter a valid domain ID. It must be a valid UUID")
+	}
+
+	d, err := newClient(c).GetDistribution(id)
+	if err != nil {
+		return nil, exitErr(err)
+	}
+	return d, nil
+}
+
+// updateRules validates the new rules and saves rules as the rules of d.
+// Unknown actions need --force.
+func updateRules(c *cli.Context, d *client.Distribution, rules []client.Rule) error {
+	warnings, err := validateRules(knownRuleActions(d.Rules), d.Rules, rules)
+	if err != nil {
+		return err
+	}
+	if err := checkWarnings(c, warnings); err != nil {
+		return err
+	}
+	if rules == nil {
+		rules = []client.Rule{}
+	}
+	after := copyDistribution(d)
+	after.Rules = rules
+	return updateDistribution(c, d, &after)
+}
+
+// ruleIndex returns the 0-based position of the rule selected by the 1-based
+// flag name.
+func ruleIndex(c *cli.Context, name string, rules []client.Rule) (int, error) {
+	i := c.Int(name)
+	if i < 1 || i > len(rules) {
+		return 0, validationErr(fmt.Sprintf("--%s must be between 1 and %d", name, len(rules)))
+	}
+	return i - 1, nil
+}
+
+// ruleTest is the result of "domain rules test".
+type ruleTest struct {
+	Path     string    `json:"path"`
+	Rule     *ruleRow  `json:"rule"`
+	Settings []setting `json:"settings"`
+}
+
+// effectiveSettings returns the settings of d that apply to a path once
+// actions of the matching rule are taken into account.
+func effectiveSettings(d *client.Distribution, actions []string) []setting {
+	ruled := map[string]bool{}
+	for _, a := range actions {
+		ruled[strings.TrimPrefix(a, "no-")] = !strings.HasPrefix(a, "no-")
+	}
+
+	value := func(name string, def bool) setting {
+		if on, ok := ruled[name]; ok {
+			return setting{Name: name, Value: strconv.FormatBool(on), Source: "rule"}
+		}
+		return setting{Name: name, Value: strconv.FormatBool(def), Source: "domain"}
+	}
+
+	cache := value("cache", true)
+	settings := []setting{cache}
+	if cache.Value == "true" {
+		settings = append(settings, setting{Name: "default-cache-time", Value: d.DefaultCacheTime.String(), Source: "domain"})
+	}
+	for _, t := range distributionToggles {
+		settings = append(settings, value(t.flag, *t.field(d)))
+	}
+	return settings
+}
+
+var domainRulesCommand = &cli.Command{
+	Name:  "rules",
+	Usage: "manage the path rules of a domain",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "ls",
+			Usage:     "List the rules of a domain in the order they are applied",
+			ArgsUsage: "<domain-id>",
+			Action: func(c *cli.Context) error {
+				d, err := distributionArg(c)
+				if err != nil {
+					return err
+				}
+
+				rows := []ruleRow{}
+				for i, r := range d.Rules {
+					rows = append(rows, ruleRow{Index: i + 1, Pattern: r.Pattern, Actions: r.Actions})
+				}
+				return render(c, rows, func() {
+					for _, r := range rows {
+						fmt.Printf("%d. %s: %s\n", r.Index, r.Pattern, strings.Join(r.Actions, ", "))
+					}
+				})
+			},
+		},
+		{
+			Name:      "add",
+			Usage:     "Add a rule to a domain",
+			ArgsUsage: "<domain-id>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "pattern", Usage: "path pattern, * matches any characters", Required: true},
+				&cli.StringFlag{Name: "actions", Usage: "comma separated actions (" + strings.Join(ruleActions(), "|") + ")", Required: true},
+				&cli.IntFlag{Name: "position", Usage: "1-based position of the new rule, defaults to the end"},
+				forceFlag,
+			},
+			Action: func(c *cli.Context) error {
+				d, err := distributionArg(c)
+				if err != nil {
+					return err
+				}
+
+				rule := client.Rule{Pattern: c.String("pattern"), Actions: splitActions(c.String("actions"))}
+				pos := len(d.Rules)
+				if c.IsSet("position") {
+					pos = c.Int("position") - 1
+					if pos < 0 || pos > len(d.Rules) {
+						return validationErr(fmt.Sprintf("--position must be between 1 and %d", len(d.Rules)+1))
+					}
+				}
+
+				rules := append([]client.Rule{}, d.Rules[:pos]...)
+				rules = append(rules, rule)
+				rules = append(rules, d.Rules[pos:]...)
+				return updateRules(c, d, rules)
+			},
+		},
+		{
+			Name:      "rm",
+			Usage:     "Delete a rule of a domain by index or pattern",
+			ArgsUsage: "<domain-id>",
+			Flags: []cli.Flag{
+				&cli.IntFlag{Name: "index", Usage: "1-based index of the rule, as shown by rules ls"},
+				&cli.StringFlag{Name: "pattern", Usage: "pattern of the rule"},
+			},
+			Action: func(c *cli.Context) error {
+				d, err := distributionArg(c)
+				if err != nil {
+					return err
+				}
+
+				var rules []client.Rule
+				switch {
+				case c.IsSet("index"):
+					i, err := ruleIndex(c, "index", d.Rules)
+					if err != nil {
+						return err
+					}
+					rules = append(rules, d.Rules[:i]...)
+					rules = append(rules, d.Rules[i+1:]...)
+				case c.IsSet("pattern"):
+					for _, r := range d.Rules {
+						if r.Pattern != c.String("pattern") {
+							rules = append(rules, r)
+						}
+					}
+					if len(rules) == len(d.Rules) {
+						return cli.Exit(fmt.Sprintf("No rule with pattern %q found", c.String("pattern")), exitNotFound)
+					}
+				default:
+					return validationErr("Missing --index or --pattern")
+				}
+				return updateRules(c, d, rules)
+			},
+		},
+		{
+			Name:      "move",
+			Usage:     "Move a rule of a domain to another position",
+			ArgsUsage: "<domain-id>",
+			Flags: []cli.Flag{
+				&cli.IntFlag{Name: "from", Usage: "1-based index of the rule to move", Required: true},
+				&cli.IntFlag{Name: "to", Usage: "1-based index the rule is moved to", Required: true},
+			},
+			Action: func(c *cli.Context) error {
+				d, err := distributionArg(c)
+				if err != nil {
+					return err
+				}
+
+				from, err := ruleIndex(c, "from", d.Rules)
+				if err != nil {
+					return err
+				}
+				to, err := ruleIndex(c, "to", d.Rules)
+				if err != nil {
+					return err
+				}
+
+				rule := d.Rules[from]
+				var rules []client.Rule
+				rules = append(rules, d.Rules[:from]...)
+				rules = append(rules, d.Rules[from+1:]...)
+				rules = append(rules[:to], append([]client.Rule{rule}, rules[to:]...)...)
+				return updateRules(c, d, rules)
+			},
+		},
+		{
+			Name:      "test",
+			Usage:     "Show which rule and settings apply to a path",
+			ArgsUsage: "[<domain-id>] <path>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "file", Aliases: []string{"f"}, Usage: "read the domain from a JSON or YAML file instead of the API, a single domain or a file written by export"},
+				&cli.StringFlag{Name: "origin", Usage: "origin of the domain to test in a file written by export"},
+			},
+			Action: func(c *cli.Context) error {
+				if c.Args().Len() < 1 || (!c.IsSet("file") && c.Args().Len() < 2) {
+					return validationErr("Please enter the path to test, e.g. /static/img/hero.png")
+				}
+				path := c.Args().Get(c.Args().Len() - 1)
+
+				var d *client.Distribution
+				var err error
+				if c.IsSet("file") {
+					d, err = readTestDomain(c.String("file"), c.String("origin"))
+				} else {
+					d, err = distributionArg(c)
+				}
+				if err != nil {
+					return err
+				}
+
+				result := ruleTest{Path: path}
+				var actions []string
+				if i := client.MatchRule(d.Rules, path); i >= 0 {
+					r := d.Rules[i]
+					result.Rule = &ruleRow{Index: i + 1, Pattern: r.Pattern, Actions: r.Actions}
+					actions = r.Actions
+				}
+				result.Settings = effectiveSettings(d, actions)
+
+				return render(c, result, func() {
+					fmt.Println("Path: ", result.Path)
+					if result.Rule != nil {
+						fmt.Printf("Rule:  %d. %s: %s\n", result.Rule.Index, result.Rule.Pattern, strings.Join(result.Rule.Actions, ", "))
+					} else {
+						fmt.Println("Rule:  none, only the settings of the domain apply")
+					}
+					fmt.Println("Settings: ")
+					for _, st := range result.Settings {
+						fmt.Printf("  %s: %s (from %s)\n", st.Name, st.Value, st.Source)
+					}
+				})
+			},
+		},
+		{
+			Name:      "replace",
+			Usage:     "Replace all rules of a domain with the ones in a JSON or YAML file",
+			ArgsUsage: "<domain-id>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "file", Aliases: []string{"f"}, Usage: "file with a list of {pattern, actions}", Required: true},
+				forceFlag,
+			},
+			Action: func(c *cli.Context) error {
+				rules, err := readRules(c.String("file"))
+				if err != nil {
+					return validationErr(err.Error())
+				}
+
+				d, err := distributionArg(c)
+				if err != nil {
+					return err
+				}
+				return updateRules(c, d, rules)
+			},
+		},
+	},
+}
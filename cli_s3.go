@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/Dexecure/dexecure-cli/client"
+	"github.com/urfave/cli/v2"
+)
+
+// regionRegexp matches the names of AWS regions, e.g. us-east-1,
+// ap-southeast-3 or us-gov-west-1. New regions are added every year, so the
+// region is only checked for its format and the API checks that it exists.
+var regionRegexp = regexp.MustCompile(`^[a-z]{2}(-gov|-iso[a-z]?)?-[a-z]+-[0-9]{1,2}$`)
+
+var bucketNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// validateBucketName checks name against the naming rules of S3 buckets.
+func validateBucketName(name string) error {
+	switch {
+	case len(name) < 3 || len(name) > 63:
+		return fmt.Errorf("bucket name %q must be between 3 and 63 characters long", name)
+	case !bucketNameRegexp.MatchString(name):
+		return fmt.Errorf("bucket name %q may only contain lowercase letters, digits, dots and hyphens, and must start and end with a letter or digit", name)
+	case strings.Contains(name, ".."):
+		return fmt.Errorf("bucket name %q must not contain two adjacent dots", name)
+	case strings.Contains(name, ".-") || strings.Contains(name, "-."):
+		return fmt.Errorf("bucket name %q must not contain a dot next to a hyphen", name)
+	case net.ParseIP(name) != nil:
+		return fmt.Errorf("bucket name %q must not be formatted as an IP address", name)
+	case strings.HasPrefix(name, "xn--"):
+		return fmt.Errorf("bucket name %q must not start with xn--", name)
+	case strings.HasSuffix(name, "-s3alias"):
+		return fmt.Errorf("bucket name %q must not end with -s3alias", name)
+	}
+	return nil
+}
+
+func validateRegion(region string) error {
+	if !regionRegexp.MatchString(region) {
+		return fmt.Errorf("%q is not an AWS region, e.g. us-east-1 or eu-central-2", region)
+	}
+	return nil
+}
+
+var s3Flags = []cli.Flag{
+	&cli.StringFlag{Name: "s3-bucket", Usage: "name of a private S3 bucket to use as origin"},
+	&cli.StringFlag{Name: "s3-region", Usage: "AWS region of the bucket, e.g. us-east-1"},
+}
+
+// s3BucketFlags returns the bucket given with --s3-bucket and --s3-region,
+// or nil if neither is set.
+func s3BucketFlags(c *cli.Context) (*client.S3Bucket, error) {
+	if !c.IsSet("s3-bucket") && !c.IsSet("s3-region") {
+		return nil, nil
+	}
+
+	bucket := &client.S3Bucket{Name: c.String("s3-bucket"), Region: c.String("s3-region")}
+	if bucket.Name == "" || bucket.Region == "" {
+		return nil, validationErr("Please enter both --s3-bucket and --s3-region")
+	}
+	if err := validateBucketName(bucket.Name); err != nil {
+		return nil, validationErr("Please enter a valid --s3-bucket: " + err.Error())
+	}
+	if err := validateRegion(bucket.Region); err != nil {
+		return nil, validationErr("Please enter a valid --s3-region: " + err.Error())
+	}
+	return bucket, nil
+}
+
+// s3Origin is the origin of a distribution serving from bucket.
+func s3Origin(bucket *client.S3Bucket) string {
+	return fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket.Name, bucket.Region)
+}
+
+var domainS3Command = &cli.Command{
+	Name:  "s3",
+	Usage: "manage the private S3 bucket origin of a domain",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "set",
+			Usage:     "Serve a domain from a private S3 bucket",
+			ArgsUsage: "<domain-id>",
+			Flags:     s3Flags,
+			Action: func(c *cli.Context) error {
+				bucket, err := s3BucketFlags(c)
+				if err != nil {
+					return err
+				}
+				if bucket == nil {
+					return validationErr("Please enter --s3-bucket and --s3-region")
+				}
+
+				d, err := distributionArg(c)
+				if err != nil {
+					return err
+				}
+
+				after := copyDistribution(d)
+				after.S3BucketIsOrigin = true
+				after.S3Bucket = *bucket
+				return updateDistribution(c, d, &after)
+			},
+		},
+		{
+			Name:      "unset",
+			Usage:     "Stop serving a domain from an S3 bucket",
+			ArgsUsage: "<domain-id>",
+			Action: func(c *cli.Context) error {
+				d, err := distributionArg(c)
+				if err != nil {
+					return err
+				}
+
+				after := copyDistribution(d)
+				after.S3BucketIsOrigin = false
+				after.S3Bucket = client.S3Bucket{}
+				return updateDistribution(c, d, &after)
+			},
+		},
+	},
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestValidateRegion(t *testing.T) {
+	valid := []string{
+		"us-east-1", "us-west-2", "eu-central-2", "ap-southeast-3", "ap-south-2",
+		"me-central-1", "il-central-1", "ca-west-1", "cn-northwest-1", "us-gov-west-1", "us-isob-east-1",
+	}
+	for _, region := range valid {
+		if err := validateRegion(region); err != nil {
+			t.Errorf("validateRegion(%q) = %v, want nil", region, err)
+		}
+	}
+
+	invalid := []string{"", "us-east", "US-EAST-1", "us_east_1", "useast1", "us-east-1a", "s3.us-east-1", "us-east-100"}
+	for _, region := range invalid {
+		if err := validateRegion(region); err == nil {
+			t.Errorf("validateRegion(%q) = nil, want an error", region)
+		}
+	}
+}
+
+func TestValidateBucketName(t *testing.T) {
+	valid := []string{"my-bucket", "abc", "assets.example.com", "a1-b2.c3"}
+	for _, name := range valid {
+		if err := validateBucketName(name); err != nil {
+			t.Errorf("validateBucketName(%q) = %v, want nil", name, err)
+		}
+	}
+
+	invalid := []string{"ab", "My-Bucket", "-bucket", "bucket-", "my..bucket", "my-.bucket", "192.168.1.1", "xn--bucket", "bucket-s3alias", "my_bucket"}
+	for _, name := range invalid {
+		if err := validateBucketName(name); err == nil {
+			t.Errorf("validateBucketName(%q) = nil, want an error", name)
+		}
+	}
+}
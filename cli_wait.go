@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Dexecure/dexecure-cli/client"
+	"github.com/urfave/cli/v2"
+)
+
+// statusChange is a status a domain was seen in while waiting.
+type statusChange struct {
+	Status string    `json:"status"`
+	Time   time.Time `json:"time"`
+}
+
+// waitResult is what "domain wait" reports once the domain reached the
+// status, or failed to.
+type waitResult struct {
+	ID          string         `json:"id"`
+	Origin      string         `json:"origin"`
+	Status      string         `json:"status"`
+	Elapsed     client.Seconds `json:"elapsed"`
+	Transitions []statusChange `json:"transitions"`
+}
+
+// failedStatus reports whether a domain in status s won't reach any other
+// status without being changed.
+func failedStatus(s string) bool {
+	s = strings.ToLower(s)
+	return strings.Contains(s, "fail") || strings.Contains(s, "error")
+}
+
+// nextPollDelay returns how long to wait before polling again when the
+// status didn't change. The delay grows by half up to four times interval.
+func nextPollDelay(delay, interval time.Duration) time.Duration {
+	delay += delay / 2
+	if delay > 4*interval {
+		delay = 4 * interval
+	}
+	return delay
+}
+
+// rootContext returns the context of the top-level app, which holds the
+// global flags even when a command has a flag of the same name.
+func rootContext(c *cli.Context) *cli.Context {
+	root := c
+	for _, ctx := range c.Lineage() {
+		if ctx.App != nil {
+			root = ctx
+		}
+	}
+	return root
+}
+
+var domainWaitCommand = &cli.Command{
+	Name:      "wait",
+	Usage:     "Wait until a domain reaches a status, e.g. deployed after domain add",
+	ArgsUsage: "<domain-id>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "for", Value: "deployed", Usage: "status to wait for"},
+		&cli.DurationFlag{Name: "timeout", Value: 30 * time.Minute, Usage: "how long to wait before giving up"},
+		&cli.DurationFlag{Name: "interval", Value: 15 * time.Second, Usage: "time between checks, grows while the status doesn't change"},
+	},
+	Action: func(c *cli.Context) error {
+		want := strings.TrimSpace(c.String("for"))
+		timeout, interval := c.Duration("timeout"), c.Duration("interval")
+		switch {
+		case want == "":
+			return validationErr("Please enter the status to wait for with --for")
+		case timeout <= 0:
+			return validationErr("Please enter a --timeout greater than 0")
+		case interval <= 0:
+			return validationErr("Please enter an --interval greater than 0")
+		}
+
+		id := c.Args().First()
+		if isValidUUID(id) == false {
+			return validationErr("Please enter a valid domain ID. It must be a valid UUID")
+		}
+		if getToken(c) == "" {
+			return errNoToken
+		}
+
+		// --timeout of this command shadows the global one, which limits
+		// each API call.
+		cl := newClient(c)
+		cl.Timeout = rootContext(c).Duration("timeout")
+
+		text := c.String("output") == "text" && c.String("format") == ""
+		start := time.Now()
+		deadline := start.Add(timeout)
+		result := waitResult{ID: id, Transitions: []statusChange{}}
+		delay := interval
+		for {
+			d, err := cl.GetDistribution(id)
+			if err != nil {
+				return exitErr(err)
+			}
+			now := time.Now()
+			result.Origin = d.Origin
+			result.Elapsed = client.Seconds(now.Sub(start) / time.Second)
+
+			if d.Status != result.Status || len(result.Transitions) == 0 {
+				result.Status = d.Status
+				result.Transitions = append(result.Transitions, statusChange{Status: d.Status, Time: now.UTC()})
+				if text {
+					fmt.Printf("%s %s domain is %s\n", now.Format("15:04:05"), d.Origin, d.Status)
+				}
+				delay = interval
+			} else {
+				delay = nextPollDelay(delay, interval)
+			}
+
+			switch {
+			case d.Status == want:
+				return render(c, result, func() {
+					fmt.Printf("%s domain is %s after %s.\n", d.Origin, want, result.Elapsed)
+				})
+			case failedStatus(d.Status):
+				if err := render(c, result, func() {}); err != nil {
+					return err
+				}
+				return cli.Exit(fmt.Sprintf("%s domain is %s and won't become %s", d.Origin, d.Status, want), exitError)
+			case !now.Before(deadline):
+				if err := render(c, result, func() {}); err != nil {
+					return err
+				}
+				return cli.Exit(fmt.Sprintf("Timed out after %s: %s domain is still %s, not %s", timeout, d.Origin, d.Status, want), exitTimeout)
+			}
+
+			if left := deadline.Sub(now); delay > left {
+				delay = left
+			}
+			time.Sleep(delay)
+		}
+	},
+}
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"github.com/parnurzeal/gorequest"
+)
+
+// GetUser returns the account the token belongs to, including its plan.
+func (c *Client) GetUser() (*User, error) {
+	var user User
+	if err := c.do(gorequest.GET, "user", nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUsage returns the usage of the team for the current month.
+func (c *Client) GetUsage() (*Usage, error) {
+	var usage Usage
+	if err := c.do(gorequest.GET, "team/usage", nil, &usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
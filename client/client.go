@@ -0,0 +1,150 @@
+// Package client is a Go client for the Dexecure API.
+package client
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+const (
+	DefaultEndpoint   = "https://dao-api.dexecure.com/api/v1/"
+	DefaultMaxRetries = 3
+)
+
+// Client talks to the Dexecure API on behalf of a single API token.
+type Client struct {
+	Endpoint string
+	Token    string
+
+	// MaxRetries is how many times a failed request is retried. See
+	// retryable for which failures are retried.
+	MaxRetries int
+	// Timeout limits how long a single attempt may take. Zero means no
+	// limit.
+	Timeout time.Duration
+}
+
+func New(token string) *Client {
+	return &Client{
+		Endpoint:   DefaultEndpoint,
+		Token:      token,
+		MaxRetries: DefaultMaxRetries,
+	}
+}
+
+// envelope is the wrapper the API puts around every response body.
+type envelope struct {
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error"`
+	Data   json.RawMessage `json:"data"`
+}
+
+type errorBody struct {
+	Code        int    `json:"code"`
+	Description string `json:"description"`
+	Parameter   string `json:"parameter"`
+}
+
+// do sends a request to path and decodes the data field of the response
+// into out, which may be nil when the caller doesn't need it.
+func (c *Client) do(method, path string, body interface{}, out interface{}) error {
+	res, bdy, err := c.send(method, path, body)
+	if err != nil {
+		return err
+	}
+
+	var env envelope
+	if err := json.Unmarshal(bdy, &env); err != nil {
+		if res.StatusCode != 200 {
+			return &APIError{Status: res.StatusCode}
+		}
+		return err
+	}
+
+	if err := decodeError(res.StatusCode, env.Error); err != nil {
+		return err
+	}
+	if res.StatusCode != 200 {
+		return &APIError{Status: res.StatusCode}
+	}
+	if env.Status != 0 && env.Status != 200 {
+		return &APIError{Status: res.StatusCode, Code: env.Status}
+	}
+
+	if out == nil || len(env.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(env.Data, out)
+}
+
+// send sends a request, retrying it as long as the failure is retryable and
+// MaxRetries isn't exhausted.
+func (c *Client) send(method, path string, body interface{}) (gorequest.Response, []byte, error) {
+	for attempt := 0; ; attempt++ {
+		req := gorequest.New().
+			CustomMethod(method, strings.TrimSuffix(c.Endpoint, "/")+"/"+path).
+			Set("Authorization", c.Token)
+		if c.Timeout > 0 {
+			req = req.Timeout(c.Timeout)
+		}
+		if body != nil {
+			req = req.Send(body)
+		}
+
+		res, bdy, errs := req.EndBytes()
+		var err error
+		if len(errs) > 0 {
+			err = &NetworkError{Err: errs[0]}
+		}
+
+		if attempt >= c.MaxRetries || !retryable(method, res, err) {
+			return res, bdy, err
+		}
+		time.Sleep(retryDelay(attempt, res))
+	}
+}
+
+// decodeError turns the error field of a response into an *APIError. The
+// API reports validation errors either as a plain string or as an object.
+func decodeError(status int, raw json.RawMessage) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+
+	var description string
+	if err := json.Unmarshal(raw, &description); err == nil {
+		if description == "" {
+			return nil
+		}
+		return &APIError{Status: status, Description: description}
+	}
+
+	var eb errorBody
+	if err := json.Unmarshal(raw, &eb); err != nil {
+		return err
+	}
+	if eb.Code == 0 && eb.Description == "" {
+		return nil
+	}
+	return &APIError{
+		Status:      status,
+		Code:        eb.Code,
+		Description: eb.Description,
+		Parameter:   eb.Parameter,
+	}
+}
+
+// message sends a request whose response data is a human readable message.
+func (c *Client) message(method, path string, body interface{}) (string, error) {
+	var raw json.RawMessage
+	if err := c.do(method, path, body, &raw); err != nil {
+		return "", err
+	}
+
+	var msg string
+	json.Unmarshal(raw, &msg)
+	return msg, nil
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"encoding/json"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+type distributionList struct {
+	Distributions []Distribution `json:"distributions"`
+}
+
+func (c *Client) ListDistributions() ([]Distribution, error) {
+	var dl distributionList
+	if err := c.do(gorequest.GET, "distribution/", nil, &dl); err != nil {
+		return nil, err
+	}
+	return dl.Distributions, nil
+}
+
+// ListWebsiteDistributions returns the distributions belonging to a website.
+func (c *Client) ListWebsiteDistributions(websiteID string) ([]Distribution, error) {
+	var dl distributionList
+	if err := c.do(gorequest.GET, "distribution?websiteId="+websiteID, nil, &dl); err != nil {
+		return nil, err
+	}
+	return dl.Distributions, nil
+}
+
+func (c *Client) GetDistribution(id string) (*Distribution, error) {
+	var d Distribution
+	if err := c.do(gorequest.GET, "distribution/"+id, nil, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetDistributionJSON returns a distribution as sent by the API, including
+// the fields Distribution doesn't know about.
+func (c *Client) GetDistributionJSON(id string) (json.RawMessage, error) {
+	var raw json.RawMessage
+	if err := c.do(gorequest.GET, "distribution/"+id, nil, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// CreateDistribution adds a distribution for origin to a website.
+func (c *Client) CreateDistribution(dr DomainRequest) (string, error) {
+	return c.message(gorequest.POST, "distribution", dr)
+}
+
+// DeleteDistribution permanently deletes a distribution.
+func (c *Client) DeleteDistribution(id string) (string, error) {
+	return c.message(gorequest.DELETE, "distribution/"+id, nil)
+}
+
+// ClearCache purges the given relative urls from the cache of a
+// distribution. Use "/*" to purge everything.
+func (c *Client) ClearCache(id string, urls []string) (string, error) {
+	body := map[string][]string{"url": urls}
+	return c.message(gorequest.POST, "distribution/"+id+"/clear", body)
+}
+
+// UpdateDistribution saves the settings of d, which must have its ID set.
+// They are merged into the distribution as the API sends it, so fields
+// Distribution doesn't know about are sent back unchanged.
+func (c *Client) UpdateDistribution(d Distribution) (string, error) {
+	raw, err := c.GetDistributionJSON(d.ID)
+	if err != nil {
+		return "", err
+	}
+	body := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return "", err
+	}
+
+	typed, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(typed, &fields); err != nil {
+		return "", err
+	}
+	for name, value := range fields {
+		body[name] = value
+	}
+	return c.message(gorequest.PUT, "distribution/"+d.ID, body)
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUpdateDistributionKeepsUnknownFields(t *testing.T) {
+	var sent map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/distribution/d1" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"status": 200, "data": {"id": "d1", "origin": "example.com", "jsEnabled": false, "newField": {"a": 1}}}`))
+		case http.MethodPut:
+			body, _ := ioutil.ReadAll(r.Body)
+			if err := json.Unmarshal(body, &sent); err != nil {
+				t.Errorf("invalid body %s: %v", body, err)
+			}
+			w.Write([]byte(`{"status": 200, "data": "Distribution updated"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := New("token")
+	c.Endpoint = srv.URL + "/"
+	c.MaxRetries = 0
+	if _, err := c.UpdateDistribution(Distribution{ID: "d1", Origin: "example.com", JsEnabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	if sent["jsEnabled"] != true {
+		t.Errorf("jsEnabled = %v, want true", sent["jsEnabled"])
+	}
+	if nf, ok := sent["newField"].(map[string]interface{}); !ok || nf["a"] != 1.0 {
+		t.Errorf("newField = %v, want it sent back unchanged", sent["newField"])
+	}
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// APIError is returned when the API rejects a request.
+type APIError struct {
+	// Status is the HTTP status of the response. The API reports most
+	// failures inside a 200 response, so Code is usually more telling.
+	Status      int
+	Code        int
+	Description string
+	Parameter   string
+}
+
+func (e *APIError) Error() string {
+	if e.Description != "" {
+		return e.Description
+	}
+	return fmt.Sprintf("request to the API failed: %d %s", e.StatusCode(), http.StatusText(e.StatusCode()))
+}
+
+// StatusCode returns the status code the failure maps to, preferring the
+// code reported in the response body over the HTTP status.
+func (e *APIError) StatusCode() int {
+	if e.Code != 0 {
+		return e.Code
+	}
+	return e.Status
+}
+
+// NetworkError is returned when a request never got a response from the
+// API, e.g. because of DNS or connection failures.
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
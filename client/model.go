@@ -0,0 +1,118 @@
+package client
+
+import (
+	"time"
+)
+
+type Website struct {
+	WebsiteURL  string `json:"websiteUrl"`
+	WebsiteType string `json:"websiteType"`
+	WebsiteName string `json:"websiteName"`
+	ID          string `json:"id"`
+}
+
+type WebsiteRequest struct {
+	WebsiteURL  string `json:"websiteUrl"`
+	WebsiteType string `json:"websiteType"`
+	WebsiteName string `json:"websiteName"`
+}
+
+type DomainRequest struct {
+	WebsiteId        string    `json:"websiteId"`
+	Origin           string    `json:"origin"`
+	S3BucketIsOrigin bool      `json:"s3BucketIsOrigin,omitempty"`
+	S3Bucket         *S3Bucket `json:"s3Bucket,omitempty"`
+}
+
+type Rule struct {
+	Pattern string   `json:"pattern"`
+	Actions []string `json:"actions"`
+}
+
+// Distribution is a Dexecure domain together with its optimization settings.
+// The fields assigned by the server are omitted from JSON when empty, so
+// that a Distribution can also describe the settings of a domain to create.
+type Distribution struct {
+	ID                    string       `json:"id,omitempty"`
+	Origin                string       `json:"origin"`
+	Name                  string       `json:"name,omitempty"`
+	Type                  string       `json:"type,omitempty"`
+	Status                string       `json:"status,omitempty"`
+	WebsiteID             string       `json:"websiteId,omitempty"`
+	Region                string       `json:"region,omitempty"`
+	RootPath              string       `json:"rootPath,omitempty"`
+	CNames                []string     `json:"CNames"`
+	JsEnabled             bool         `json:"jsEnabled"`
+	CSSEnabled            bool         `json:"cssEnabled"`
+	ImageEnabled          bool         `json:"imageEnabled"`
+	SVGEnabled            bool         `json:"SVGEnabled"`
+	FontEnabled           bool         `json:"fontEnabled"`
+	ProxyEnabled          bool         `json:"proxyEnabled"`
+	CacheControlImmutable bool         `json:"cacheControlImmutable"`
+	GIFEnabled            bool         `json:"GIFEnabled"`
+	DefaultCacheTime      Seconds      `json:"defaultCacheTime"`
+	Rules                 []Rule       `json:"rules"`
+	AutoResize            bool         `json:"autoResize"`
+	AutoRotate            bool         `json:"autoRotate"`
+	HeifEnabled           bool         `json:"heifEnabled"`
+	TextDetection         bool         `json:"textDetection"`
+	FaceDetection         bool         `json:"faceDetection"`
+	Zopflipng             bool         `json:"zopflipng"`
+	ErrorCaching          ErrorCaching `json:"errorCaching"`
+	LinkCanonical         bool         `json:"linkCanonical"`
+	S3BucketIsOrigin      bool         `json:"s3BucketIsOrigin"`
+	S3Bucket              S3Bucket     `json:"s3Bucket"`
+}
+
+// S3Bucket is a private Amazon S3 bucket used as the origin of a
+// distribution.
+type S3Bucket struct {
+	Name   string `json:"name"`
+	Region string `json:"region"`
+}
+
+// ErrorCaching controls how long error responses of the origin are cached.
+type ErrorCaching struct {
+	// ServerError maps 5xx status codes to the time they are cached.
+	ServerError map[string]Seconds `json:"serverError"`
+	ClientError ClientErrorCaching `json:"clientError"`
+}
+
+type ClientErrorCaching struct {
+	Default Seconds `json:"default"`
+}
+
+type Usage struct {
+	Bandwidth     int `json:"bandwidth"`
+	Requests      int `json:"requests"`
+	Distributions int `json:"distributions"`
+}
+
+type Plan struct {
+	ID               string    `json:"id"`
+	TeamID           string    `json:"teamId"`
+	Tier             int       `json:"tier"`
+	Name             string    `json:"name"`
+	MaxDistributions int       `json:"max_distributions"`
+	MaxBandwidth     int       `json:"max_bandwidth"`
+	MaxRequests      int       `json:"max_requests"`
+	Price            int       `json:"price"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+type User struct {
+	ID                      string      `json:"id"`
+	FirstName               string      `json:"firstName"`
+	LastName                string      `json:"lastName"`
+	Email                   string      `json:"email"`
+	Role                    string      `json:"role"`
+	IsEnterprise            int         `json:"isEnterprise"`
+	FeaturePrivateS3        int         `json:"featurePrivateS3"`
+	FeatureTPO              int         `json:"featureTPO"`
+	Coupon                  interface{} `json:"Coupon"`
+	IsPaymentDetailsEntered bool        `json:"isPaymentDetailsEntered"`
+	IsPasswordEntered       bool        `json:"isPasswordEntered"`
+	IsVerified              bool        `json:"isVerified"`
+	Plan                    Plan        `json:"Plan"`
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+const (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+)
+
+// retryable reports whether a request that ended with res or err may be sent
+// again. GET and DELETE are idempotent and are retried on any network error
+// or gateway failure. Other methods are only retried when it is certain the
+// API didn't apply the request: the connection was never established, or
+// the API answered 429 or 503.
+func retryable(method string, res gorequest.Response, err error) bool {
+	idempotent := method == gorequest.GET || method == gorequest.DELETE
+
+	if err != nil {
+		if idempotent {
+			return true
+		}
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
+
+// retryDelay returns how long to wait before retrying after attempt failed.
+// It honours Retry-After on 429 and 503 responses, and otherwise backs off
+// exponentially with jitter.
+func retryDelay(attempt int, res gorequest.Response) time.Duration {
+	if res != nil && (res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusServiceUnavailable) {
+		if d, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+			return d
+		}
+	}
+
+	d := retryBaseDelay << uint(attempt)
+	if d > retryMaxDelay || d <= 0 {
+		d = retryMaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as
+// an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
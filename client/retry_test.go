@@ -0,0 +1,102 @@
+package client
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+func response(status int, retryAfter string) gorequest.Response {
+	res := &http.Response{StatusCode: status, Header: http.Header{}}
+	if retryAfter != "" {
+		res.Header.Set("Retry-After", retryAfter)
+	}
+	return res
+}
+
+func TestRetryable(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	readErr := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")}
+
+	tests := []struct {
+		name   string
+		method string
+		res    gorequest.Response
+		err    error
+		want   bool
+	}{
+		{"GET network error", gorequest.GET, nil, readErr, true},
+		{"DELETE network error", gorequest.DELETE, nil, readErr, true},
+		{"POST dial error", gorequest.POST, nil, dialErr, true},
+		{"POST read error", gorequest.POST, nil, readErr, false},
+		{"PUT other error", gorequest.PUT, nil, errors.New("boom"), false},
+		{"GET 429", gorequest.GET, response(429, ""), nil, true},
+		{"POST 429", gorequest.POST, response(429, ""), nil, true},
+		{"PUT 503", gorequest.PUT, response(503, ""), nil, true},
+		{"GET 502", gorequest.GET, response(502, ""), nil, true},
+		{"GET 504", gorequest.GET, response(504, ""), nil, true},
+		{"POST 502", gorequest.POST, response(502, ""), nil, false},
+		{"PUT 504", gorequest.PUT, response(504, ""), nil, false},
+		{"GET 500", gorequest.GET, response(500, ""), nil, false},
+		{"GET 404", gorequest.GET, response(404, ""), nil, false},
+		{"GET 200", gorequest.GET, response(200, ""), nil, false},
+	}
+	for _, tt := range tests {
+		if got := retryable(tt.method, tt.res, tt.err); got != tt.want {
+			t.Errorf("%s: retryable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempt  int
+		res      gorequest.Response
+		min, max time.Duration
+	}{
+		{"first attempt", 0, nil, 250 * time.Millisecond, 500 * time.Millisecond},
+		{"third attempt", 2, response(502, ""), time.Second, 2 * time.Second},
+		{"capped", 10, nil, retryMaxDelay / 2, retryMaxDelay},
+		{"overflow", 80, nil, retryMaxDelay / 2, retryMaxDelay},
+		{"Retry-After on 429", 0, response(429, "7"), 7 * time.Second, 7 * time.Second},
+		{"Retry-After on 503", 5, response(503, "0"), 0, 0},
+		{"Retry-After ignored on 502", 0, response(502, "7"), 250 * time.Millisecond, 500 * time.Millisecond},
+		{"invalid Retry-After", 0, response(429, "soon"), 250 * time.Millisecond, 500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if d := retryDelay(tt.attempt, tt.res); d < tt.min || d > tt.max {
+				t.Errorf("%s: retryDelay = %v, want between %v and %v", tt.name, d, tt.min, tt.max)
+				break
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value    string
+		min, max time.Duration
+		ok       bool
+	}{
+		{"", 0, 0, false},
+		{"0", 0, 0, true},
+		{"120", 2 * time.Minute, 2 * time.Minute, true},
+		{"-5", 0, 0, false},
+		{"1.5", 0, 0, false},
+		{"soon", 0, 0, false},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 55 * time.Second, time.Minute, true},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0, true},
+	}
+	for _, tt := range tests {
+		d, ok := parseRetryAfter(tt.value)
+		if ok != tt.ok || d < tt.min || d > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want between %v and %v, %v", tt.value, d, ok, tt.min, tt.max, tt.ok)
+		}
+	}
+}
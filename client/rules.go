@@ -0,0 +1,44 @@
+package client
+
+import (
+	"strings"
+)
+
+// MatchPath reports whether path matches pattern. A * in the pattern matches
+// any run of characters, including /, and the whole path has to match. The
+// query string of path is ignored.
+func MatchPath(pattern, path string) bool {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == path
+	}
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	path = path[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(path, part)
+		if i < 0 {
+			return false
+		}
+		path = path[i+len(part):]
+	}
+	return strings.HasSuffix(path, last)
+}
+
+// MatchRule returns the index of the rule applied to path, which is the first
+// one whose pattern matches, or -1 when no rule matches.
+func MatchRule(rules []Rule, path string) int {
+	for i, r := range rules {
+		if MatchPath(r.Pattern, path) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,62 @@
+package client
+
+import "testing"
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/", "/", true},
+		{"/index.html", "/index.html", true},
+		{"/index.html", "/index.htm", false},
+		{"/index.html", "/index.html/", false},
+		{"/static/*", "/static/img/hero.png", true},
+		{"/static/*", "/static/", true},
+		{"/static/*", "/static", false},
+		{"/static/*", "/assets/static/a.js", false},
+		{"*.png", "/img/hero.png", true},
+		{"*.png", "/img/hero.png.gz", false},
+		{"/img/*.png", "/img/a/b/c.png", true},
+		{"/img/*.png", "/css/a.png", false},
+		{"/a/*/b/*.js", "/a/x/y/b/z.js", true},
+		{"/a/*/b/*.js", "/a/x/y/c/z.js", false},
+		{"/a*a", "/a", false},
+		{"*", "/anything?x=1", true},
+		{"/api/*", "/api/users?page=2", true},
+		{"/page", "/page?utm=x", true},
+		{"/page", "/page#top", true},
+		{"/img/*.png", "/img/a.jpg?f=.png", false},
+	}
+	for _, tt := range tests {
+		if got := MatchPath(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("MatchPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMatchRule(t *testing.T) {
+	rules := []Rule{
+		{Pattern: "/static/img/*", Actions: []string{"image"}},
+		{Pattern: "/static/*", Actions: []string{"no-cache"}},
+		{Pattern: "*.js", Actions: []string{"js"}},
+	}
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/static/img/hero.png", 0},
+		{"/static/css/site.css", 1},
+		{"/static/app.js", 1},
+		{"/app.js", 2},
+		{"/index.html", -1},
+	}
+	for _, tt := range tests {
+		if got := MatchRule(rules, tt.path); got != tt.want {
+			t.Errorf("MatchRule(%q) = %d, want %d", tt.path, got, tt.want)
+		}
+	}
+	if got := MatchRule(nil, "/"); got != -1 {
+		t.Errorf("MatchRule of no rules = %d, want -1", got)
+	}
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Seconds is a duration in whole seconds, the unit of all cache times of
+// the API. It is sent to the API as a number of seconds and printed in a
+// human readable form such as 1d or 1m30s.
+type Seconds int
+
+const (
+	Minute Seconds = 60
+	Hour           = 60 * Minute
+	Day            = 24 * Hour
+	Week           = 7 * Day
+	Year           = 365 * Day
+)
+
+var secondsUnits = map[string]Seconds{"y": Year, "w": Week, "d": Day, "h": Hour, "m": Minute, "s": 1}
+
+// maxSeconds is the longest duration Seconds can hold.
+const maxSeconds = Seconds(^uint(0) >> 1)
+
+var secondsRegexp = regexp.MustCompile(`^(\d+[ywdhms])+$`)
+var secondsPartRegexp = regexp.MustCompile(`(\d+)([ywdhms])`)
+
+func (s Seconds) String() string {
+	if s == 0 {
+		return "0s"
+	}
+
+	var b strings.Builder
+	if s < 0 {
+		b.WriteByte('-')
+		s = -s
+	}
+	for _, unit := range []string{"y", "d", "h", "m", "s"} {
+		size := secondsUnits[unit]
+		if n := s / size; n > 0 {
+			fmt.Fprintf(&b, "%d%s", n, unit)
+			s -= n * size
+		}
+	}
+	return b.String()
+}
+
+// ParseSeconds parses a number of seconds, or a duration made of numbers
+// followed by y, w, d, h, m or s such as 90s, 15m, 7d or 1h30m. A year is
+// 365 days.
+func ParseSeconds(value string) (Seconds, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "-") {
+		return 0, fmt.Errorf("duration %q must not be negative", value)
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		return Seconds(n), nil
+	}
+	if !secondsRegexp.MatchString(value) {
+		return 0, fmt.Errorf("invalid duration %q, use e.g. 90s, 15m, 12h, 7d or 1y", value)
+	}
+
+	var s Seconds
+	for _, part := range secondsPartRegexp.FindAllStringSubmatch(value, -1) {
+		n, err := strconv.Atoi(part[1])
+		if err != nil {
+			return 0, fmt.Errorf("duration %q is too long", value)
+		}
+		unit := secondsUnits[part[2]]
+		if Seconds(n) > (maxSeconds-s)/unit {
+			return 0, fmt.Errorf("duration %q is too long", value)
+		}
+		s += Seconds(n) * unit
+	}
+	return s, nil
+}
+
+// UnmarshalJSON accepts a number of seconds, as sent by the API, or a
+// string understood by ParseSeconds, as written in files by hand.
+func (s *Seconds) UnmarshalJSON(b []byte) error {
+	var n float64
+	if err := json.Unmarshal(b, &n); err == nil {
+		*s = Seconds(n)
+		return nil
+	}
+
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return fmt.Errorf("invalid duration %s", b)
+	}
+	v, err := ParseSeconds(str)
+	if err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
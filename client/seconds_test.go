@@ -0,0 +1,106 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseSeconds(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Seconds
+		ok    bool
+	}{
+		{"0", 0, true},
+		{"90", 90, true},
+		{" 3600 ", Hour, true},
+		{"90s", 90, true},
+		{"15m", 15 * Minute, true},
+		{"12h", 12 * Hour, true},
+		{"7d", Week, true},
+		{"2w", 2 * Week, true},
+		{"1y", 365 * Day, true},
+		{"1h30m", Hour + 30*Minute, true},
+		{"1d12h", Day + 12*Hour, true},
+		{"1m1m", 2 * Minute, true},
+		{"", 0, false},
+		{"-5", 0, false},
+		{"-1h", 0, false},
+		{"1.5h", 0, false},
+		{"h", 0, false},
+		{"10x", 0, false},
+		{"1h 30m", 0, false},
+		{"1H", 0, false},
+		{"292471208677y", 292471208677 * Year, true},
+		{"292471208678y", 0, false},
+		{"584942417355y", 0, false},
+		{"292471208677y1y", 0, false},
+		{"99999999999999999999s", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseSeconds(tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseSeconds(%q) error = %v, want ok = %v", tt.value, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSeconds(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSecondsString(t *testing.T) {
+	tests := []struct {
+		s    Seconds
+		want string
+	}{
+		{0, "0s"},
+		{1, "1s"},
+		{59, "59s"},
+		{60, "1m"},
+		{90, "1m30s"},
+		{Hour, "1h"},
+		{Day, "1d"},
+		{Week, "7d"},
+		{Year, "1y"},
+		{Year + Day + Hour + Minute + 1, "1y1d1h1m1s"},
+		{-90, "-1m30s"},
+	}
+	for _, tt := range tests {
+		if got := tt.s.String(); got != tt.want {
+			t.Errorf("Seconds(%d).String() = %q, want %q", int(tt.s), got, tt.want)
+		}
+		if tt.s < 0 {
+			continue
+		}
+		if back, err := ParseSeconds(tt.want); err != nil || back != tt.s {
+			t.Errorf("ParseSeconds(%q) = %d, %v, want %d", tt.want, back, err, tt.s)
+		}
+	}
+}
+
+func TestSecondsUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json string
+		want Seconds
+		ok   bool
+	}{
+		{`86400`, Day, true},
+		{`"1d"`, Day, true},
+		{`"90"`, 90, true},
+		{`"soon"`, 0, false},
+		{`true`, 0, false},
+	}
+	for _, tt := range tests {
+		var s Seconds
+		err := json.Unmarshal([]byte(tt.json), &s)
+		if (err == nil) != tt.ok || s != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, %v, want %d, ok = %v", tt.json, s, err, tt.want, tt.ok)
+		}
+	}
+
+	b, err := json.Marshal(Day)
+	if err != nil || string(b) != "86400" {
+		t.Errorf("Marshal(Day) = %s, %v, want 86400", b, err)
+	}
+}
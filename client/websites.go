@@ -0,0 +1,31 @@
+package client
+
+import (
+	"github.com/parnurzeal/gorequest"
+)
+
+func (c *Client) ListWebsites() ([]Website, error) {
+	var websites []Website
+	if err := c.do(gorequest.GET, "website/", nil, &websites); err != nil {
+		return nil, err
+	}
+	return websites, nil
+}
+
+func (c *Client) GetWebsite(id string) (*Website, error) {
+	var website Website
+	if err := c.do(gorequest.GET, "website/"+id, nil, &website); err != nil {
+		return nil, err
+	}
+	return &website, nil
+}
+
+// CreateWebsite adds a website and returns the message sent back by the API.
+func (c *Client) CreateWebsite(wr WebsiteRequest) (string, error) {
+	return c.message(gorequest.POST, "website", wr)
+}
+
+// DeleteWebsite permanently deletes a website.
+func (c *Client) DeleteWebsite(id string) (string, error) {
+	return c.message(gorequest.DELETE, "website/"+id, nil)
+}
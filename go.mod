@@ -10,6 +10,7 @@ require (
 	github.com/tucnak/store v0.0.0-20170905113834-b02ecdcc6dfb
 	github.com/urfave/cli/v2 v2.2.0
 	golang.org/x/net v0.0.0-20200202094626-16171245cfb2 // indirect
-	gopkg.in/yaml.v2 v2.2.8 // indirect
+	golang.org/x/term v0.0.0-20201126162022-7de9c90e9dd1
+	gopkg.in/yaml.v2 v2.2.8
 	moul.io/http2curl v1.0.0 // indirect
 )